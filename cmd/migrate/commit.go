@@ -0,0 +1,78 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+
+	"rewrite-migrate-java/pkg/journal"
+	"rewrite-migrate-java/pkg/project"
+	"rewrite-migrate-java/pkg/recipe"
+)
+
+// commitRecipes returns the leaves of r in the order they run, each recipe once however often
+// a composite chain includes it
+func commitRecipes(r recipe.Recipe) []recipe.Recipe {
+	seen := make(map[string]bool)
+	var recipes []recipe.Recipe
+	for _, leaf := range recipe.Leaves(r) {
+		if identity := recipe.Identity(leaf); !seen[identity] {
+			seen[identity] = true
+			recipes = append(recipes, leaf)
+		}
+	}
+	return recipes
+}
+
+// commitPerRecipe applies each leaf recipe in turn and commits its changes separately.
+// All recipes share runJournal, so undo restores the state before the first recipe.
+func commitPerRecipe(projectPath string, migrationRecipe recipe.Recipe, runJournal *journal.Journal) error {
+	clean, err := project.IsClean(projectPath)
+	if err != nil {
+		return fmt.Errorf("failed to check working tree: %w", err)
+	}
+	if !clean {
+		return fmt.Errorf("working tree has uncommitted changes; commit or stash them before running with -commit-per-recipe")
+	}
+
+	commits := 0
+	for _, child := range commitRecipes(migrationRecipe) {
+		logger.Info("Applying recipe", "recipe", child.GetDisplayName())
+		changes, err := processProject(projectPath, child, nil, runJournal)
+		if err != nil {
+			return fmt.Errorf("recipe %s failed: %w", child.GetDisplayName(), err)
+		}
+		if len(changes) == 0 {
+			logger.Info("No changes, nothing to commit", "recipe", child.GetDisplayName())
+			continue
+		}
+
+		paths := make([]string, len(changes))
+		for i, change := range changes {
+			paths[i] = change.Path
+		}
+		if err := project.Commit(projectPath, commitMessage(child, paths), paths); err != nil {
+			return fmt.Errorf("failed to commit changes of %s: %w", child.GetDisplayName(), err)
+		}
+		commits++
+		logger.Info("Committed changes", "recipe", child.GetDisplayName(), "files", len(paths))
+	}
+
+	logger.Info("Created commits", "commits", commits)
+	return nil
+}
+
+// commitMessage describes the changes a recipe made to paths
+func commitMessage(r recipe.Recipe, paths []string) string {
+	var sb strings.Builder
+	sb.WriteString(r.GetDisplayName())
+	sb.WriteString("\n\n")
+	if description := r.GetDescription(); description != "" {
+		sb.WriteString(description)
+		sb.WriteString("\n\n")
+	}
+	sb.WriteString("Changed files:\n")
+	for _, path := range paths {
+		fmt.Fprintf(&sb, "- %s\n", path)
+	}
+	return sb.String()
+}
@@ -0,0 +1,114 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"path/filepath"
+
+	"rewrite-migrate-java/pkg/config"
+	"rewrite-migrate-java/pkg/migrate"
+	"rewrite-migrate-java/pkg/recipe"
+)
+
+// loadConfig reads the -config file or the project's rewrite.yml, if any. It also returns
+// the directory relative output paths of the config are resolved against.
+func loadConfig(projectPath string) (*config.Config, string, error) {
+	path := *configPath
+	if path == "" {
+		path = config.Find(projectPath)
+	}
+	if path == "" {
+		return &config.Config{}, projectPath, nil
+	}
+
+	logger.Info("Using config", "path", path)
+	cfg, err := config.Load(path)
+	return cfg, filepath.Dir(path), err
+}
+
+// applyConfig copies configured settings onto every flag not set on the command line.
+// Relative output paths of the config are taken relative to dir, the directory of the config
+// file, so that they do not depend on where the tool is started.
+func applyConfig(cfg *config.Config, dir string, flags *flag.FlagSet) {
+	explicit := make(map[string]bool)
+	flags.Visit(func(f *flag.Flag) {
+		explicit[f.Name] = true
+	})
+
+	if !explicit["version"] && cfg.Version != 0 {
+		*version = cfg.Version
+	}
+	if !explicit["src"] && len(cfg.SourceRoots) > 0 {
+		srcDirs = cfg.SourceRoots
+	}
+	if !explicit["include"] && len(cfg.Include) > 0 {
+		includes = cfg.Include
+	}
+	if !explicit["exclude"] && len(cfg.Exclude) > 0 {
+		excludes = cfg.Exclude
+	}
+	if !explicit["skip-generated"] && cfg.SkipGenerated != nil {
+		*skipGenerated = *cfg.SkipGenerated
+	}
+	if !explicit["dry-run"] && cfg.Output.DryRun != nil {
+		*dryRun = *cfg.Output.DryRun
+	}
+	if !explicit["diff-context"] && cfg.Output.DiffContext != nil {
+		*diffContext = *cfg.Output.DiffContext
+	}
+	if !explicit["patch-out"] && cfg.Output.PatchOut != "" {
+		*patchOut = resolvePath(dir, cfg.Output.PatchOut)
+	}
+	if !explicit["report"] && cfg.Output.Report != "" {
+		*reportOut = resolvePath(dir, cfg.Output.Report)
+	}
+	if !explicit["jobs"] && cfg.Output.Jobs > 0 {
+		*jobs = cfg.Output.Jobs
+	}
+
+	// An explicit -version selects the version migration over configured recipes
+	if explicit["version"] {
+		cfg.Recipes = nil
+	}
+}
+
+// resolvePath returns path relative to dir unless it is absolute
+func resolvePath(dir, path string) string {
+	if filepath.IsAbs(path) {
+		return path
+	}
+	return filepath.Join(dir, path)
+}
+
+// configuredRecipesName is the name of the composite of several configured recipes
+const configuredRecipesName = toolName + ".ConfiguredRecipes"
+
+// selectRecipe instantiates the configured recipes, falling back to the migration for -version
+func selectRecipe(cfg *config.Config) (recipe.Recipe, error) {
+	if len(cfg.Recipes) == 0 {
+		return migrate.NewJavaVersionMigration(*version)
+	}
+
+	registry := migrate.NewRegistry()
+	recipes := make([]recipe.Recipe, 0, len(cfg.Recipes))
+	for _, rc := range cfg.Recipes {
+		r, err := registry.New(rc.Name, rc.Options)
+		if err != nil {
+			return nil, err
+		}
+		recipes = append(recipes, r)
+	}
+
+	if len(recipes) == 1 {
+		return recipes[0], nil
+	}
+	return &recipe.CompositeRecipe{
+		BaseRecipe: &recipe.BaseRecipe{
+			// The name identifies the runs of the configured recipes in caches, reports and journals
+			Name:        configuredRecipesName,
+			DisplayName: "Configured recipes",
+			Description: fmt.Sprintf("Runs the %d recipes listed in the project configuration.", len(recipes)),
+		},
+		Recipes: recipes,
+	}, nil
+}
@@ -0,0 +1,178 @@
+package main
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+
+	"rewrite-migrate-java/pkg/diff"
+	"rewrite-migrate-java/pkg/java"
+	"rewrite-migrate-java/pkg/recipe"
+	"rewrite-migrate-java/pkg/report"
+	"rewrite-migrate-java/pkg/runner"
+)
+
+// hunkReviewer asks the user to accept or reject every hunk proposed by each recipe
+type hunkReviewer struct {
+	in *bufio.Reader
+	// acceptAll holds the identities of the recipes whose remaining hunks are accepted
+	// without asking
+	acceptAll map[string]bool
+	// quit is set once the user stops reviewing; every later hunk is rejected
+	quit bool
+}
+
+func newHunkReviewer(in io.Reader) *hunkReviewer {
+	return &hunkReviewer{
+		in:        bufio.NewReader(in),
+		acceptAll: make(map[string]bool),
+	}
+}
+
+// reviewStep is a run of leaf recipes whose hunks are reviewed together. The leaves of a
+// step that changed the file share a name, so the net change of a chain such as the
+// UpgradeJavaVersion steps of a Java migration is asked about once.
+type reviewStep struct {
+	leaves []recipe.Recipe
+	// changed is the last leaf of the step that changed the file; its change is reviewed
+	changed recipe.Recipe
+}
+
+// reviewSteps splits the leaf results of a file into review steps. A step ends before a leaf
+// that changed the file under another name than the leaves that changed it so far.
+func reviewSteps(results []recipe.RecipeResult) []reviewStep {
+	var steps []reviewStep
+	for _, result := range results {
+		n := len(steps)
+		if n == 0 || (result.Changed() && steps[n-1].changed != nil && steps[n-1].changed.GetName() != result.Recipe.GetName()) {
+			steps = append(steps, reviewStep{})
+		}
+		step := &steps[len(steps)-1]
+		step.leaves = append(step.leaves, result.Recipe)
+		if result.Changed() {
+			step.changed = result.Recipe
+		}
+	}
+	return steps
+}
+
+// review runs the recipes of a changed file one step at a time and keeps only the hunks the
+// user accepts. Each step runs on the content accepted so far, so later recipes never propose
+// hunks against changes that were rejected. The result is updated in place.
+func (r *hunkReviewer) review(result *runner.Result, ctx *recipe.ExecutionContext) ([]report.Decision, error) {
+	path := result.Path
+	original := result.RecipeResults[0].Before
+	content := original
+
+	var results []recipe.RecipeResult
+	var decisions []report.Decision
+	for _, step := range reviewSteps(result.RecipeResults) {
+		named := step.changed
+		if named == nil {
+			named = step.leaves[0]
+		}
+
+		transformed := content
+		var stepResults []recipe.RecipeResult
+		for _, leaf := range step.leaves {
+			sourceFile, err := java.ParseSource(path, transformed)
+			if err != nil {
+				return nil, err
+			}
+			next, leafResults, err := recipe.Apply(leaf, sourceFile, ctx)
+			if err != nil {
+				return nil, fmt.Errorf("failed to transform file %s: %w", path, err)
+			}
+			transformed = next.GetContent()
+			stepResults = append(stepResults, leafResults...)
+		}
+
+		var accepted []diff.Hunk
+		for _, hunk := range diff.Hunks(content, transformed, *diffContext) {
+			ok, err := r.decide(path, named, hunk)
+			if err != nil {
+				return nil, err
+			}
+			decisions = append(decisions, report.Decision{
+				Recipe:   named.GetName(),
+				Hunk:     hunk.Header(),
+				Accepted: ok,
+			})
+			if ok {
+				accepted = append(accepted, hunk)
+			}
+		}
+
+		// The reviewed change of the step is attributed to the leaf that names it
+		reviewed := diff.ApplyHunks(content, accepted)
+		before := content
+		for _, stepResult := range stepResults {
+			stepResult.Before, stepResult.After = before, before
+			if stepResult.Recipe == named {
+				stepResult.After = reviewed
+				before = reviewed
+			}
+			results = append(results, stepResult)
+		}
+		content = reviewed
+	}
+
+	result.RecipeResults = results
+	if content == original {
+		result.After = result.Before
+		return decisions, nil
+	}
+
+	after, err := result.Format.Encode(content)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode file %s: %w", path, err)
+	}
+	result.After = string(after)
+	return decisions, nil
+}
+
+// decide shows a hunk and asks whether to apply it
+func (r *hunkReviewer) decide(path string, leaf recipe.Recipe, hunk diff.Hunk) (bool, error) {
+	if r.quit {
+		return false, nil
+	}
+	if r.acceptAll[recipe.Identity(leaf)] {
+		return true, nil
+	}
+
+	rendered := hunk.String()
+	if colorEnabled(out) {
+		rendered = diff.Colorize(rendered)
+	}
+	fmt.Fprintf(out, "\n[%s] %s\n%s", leaf.GetDisplayName(), path, rendered)
+
+	for {
+		fmt.Fprint(out, "Apply this hunk? [y]es, [n]o, [a]ll remaining from this recipe, [q]uit: ")
+		line, err := r.in.ReadString('\n')
+		if err != nil && !errors.Is(err, io.EOF) {
+			return false, fmt.Errorf("failed to read answer: %w", err)
+		}
+
+		switch strings.ToLower(strings.TrimSpace(line)) {
+		case "y", "yes":
+			return true, nil
+		case "n", "no":
+			return false, nil
+		case "a", "all":
+			r.acceptAll[recipe.Identity(leaf)] = true
+			return true, nil
+		case "q", "quit":
+			r.quit = true
+			return false, nil
+		}
+
+		// Without further input there is nobody left to ask
+		if errors.Is(err, io.EOF) {
+			fmt.Fprintln(out)
+			r.quit = true
+			return false, nil
+		}
+	}
+}
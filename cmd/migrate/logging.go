@@ -0,0 +1,69 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+)
+
+var (
+	verbose   *bool
+	quiet     *bool
+	logFormat *string
+)
+
+// defineLoggingFlags defines -v, -q and -log-format on flags
+func defineLoggingFlags(flags *flag.FlagSet) {
+	verbose = flags.Bool("v", false, "Log every visited and skipped file")
+	quiet = flags.Bool("q", false, "Only log warnings and errors, and show a progress line")
+	logFormat = flags.String("log-format", "text", "Log format: text or json")
+}
+
+// logger receives structured logs on logOutput
+var logger = slog.New(slog.NewTextHandler(os.Stderr, nil))
+
+// logOutput is where logs and the progress line are written, stderr unless run is told otherwise
+var logOutput io.Writer = os.Stderr
+
+// summaryLogger writes the progress summary of -q to logOutput when logs are JSON, so the
+// summary is a record of the log stream rather than a plain line in it. Unlike logger, it is
+// not limited to warnings by -q. It is nil for text logs.
+var summaryLogger *slog.Logger
+
+// setupLogging configures logger, and the logger recipes use, from -v, -q and -log-format
+func setupLogging(w io.Writer) error {
+	opts := &slog.HandlerOptions{Level: slog.LevelInfo}
+	switch {
+	case *verbose && *quiet:
+		return fmt.Errorf("-v and -q cannot be combined")
+	case *verbose:
+		opts.Level = slog.LevelDebug
+	case *quiet:
+		opts.Level = slog.LevelWarn
+	}
+
+	var handler slog.Handler
+	summaryLogger = nil
+	switch *logFormat {
+	case "text":
+		handler = slog.NewTextHandler(w, opts)
+	case "json":
+		handler = slog.NewJSONHandler(w, opts)
+		summaryLogger = slog.New(slog.NewJSONHandler(w, nil))
+	default:
+		return fmt.Errorf("unsupported log format %q, expected text or json", *logFormat)
+	}
+
+	logOutput = w
+	logger = slog.New(handler)
+	slog.SetDefault(logger)
+	return nil
+}
+
+// failed logs msg as an error and returns exit status 1
+func failed(msg string, args ...interface{}) int {
+	logger.Error(msg, args...)
+	return 1
+}
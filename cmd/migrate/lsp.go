@@ -0,0 +1,19 @@
+package main
+
+import (
+	"context"
+	"io"
+
+	"rewrite-migrate-java/pkg/lsp"
+	"rewrite-migrate-java/pkg/migrate"
+)
+
+// serveLSP runs a language server over stdin and stdout until the client exits and returns
+// the exit status
+func serveLSP(stdout io.Writer) int {
+	server := lsp.NewServer(migrate.NewRegistry())
+	if err := server.Serve(context.Background(), stdin, stdout); err != nil {
+		return failed("Language server failed", "error", err)
+	}
+	return 0
+}
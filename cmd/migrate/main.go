@@ -2,202 +2,516 @@ package main
 
 import (
 	"context"
+	"errors"
 	"flag"
 	"fmt"
-	"io/fs"
-	"log"
+	"io"
+	"log/slog"
 	"os"
 	"path/filepath"
+	"runtime"
+	"runtime/debug"
 	"strings"
 
-	"rewrite-migrate-java/pkg/java"
-	"rewrite-migrate-java/pkg/migrate"
+	"rewrite-migrate-java/pkg/cache"
+	"rewrite-migrate-java/pkg/diff"
+	"rewrite-migrate-java/pkg/journal"
+	"rewrite-migrate-java/pkg/project"
 	"rewrite-migrate-java/pkg/recipe"
+	"rewrite-migrate-java/pkg/report"
+	"rewrite-migrate-java/pkg/runner"
+	"rewrite-migrate-java/pkg/sarif"
 )
 
+// Flags are defined anew by defineFlags on every run
 var (
-	version = flag.Int("version", 17, "Target Java version (8, 11, 17, 21)")
-	srcDir  = flag.String("src", "src/main/java", "Source directory to scan")
-	dryRun  = flag.Bool("dry-run", false, "Show what would be changed without applying changes")
+	version *int
+	dryRun  *bool
+
+	diffContext *int
+	patchOut    *string
+	reportOut   *string
+	useCache    *bool
+	jobs        *int
+
+	skipGenerated *bool
+	includes      stringList
+	excludes      stringList
+	srcDirs       stringList
+
+	configPath *string
+
+	since *string
+
+	interactive *bool
+
+	commitEach *bool
+
+	failOnChange *bool
+	format       *string
 )
 
-func main() {
-	flag.Parse()
+var (
+	// out receives diffs and prompts; it is stderr when stdout carries a machine-readable format
+	out io.Writer = os.Stdout
 
-	if flag.NArg() < 1 {
-		fmt.Fprintf(os.Stderr, "Usage: %s [options] <project-path>\n", os.Args[0])
-		flag.PrintDefaults()
-		os.Exit(1)
-	}
+	// stdin is where interactive answers are read from
+	stdin io.Reader = os.Stdin
+
+	// reviewer asks which hunks to apply in interactive mode; it is nil otherwise
+	reviewer *hunkReviewer
+)
 
-	projectPath := flag.Arg(0)
+// defineFlags defines the options of a migration run on flags, resetting them to their defaults
+func defineFlags(flags *flag.FlagSet) {
+	version = flags.Int("version", 17, "Target Java version (6, 7, 8, 11, 17, 21, 25)")
+	dryRun = flags.Bool("dry-run", false, "Show what would be changed without applying changes")
 
-	// Initialize migration recipe based on target version
-	var migrationRecipe recipe.Recipe
-	switch *version {
-	case 11:
-		migrationRecipe = migrate.NewJava8ToJava11()
-	case 17:
-		migrationRecipe = migrate.NewUpgradeToJava17()
-	case 21:
-		migrationRecipe = migrate.NewUpgradeToJava21()
-	default:
-		migrationRecipe = migrate.NewUpgradeJavaVersion(*version)
+	diffContext = flags.Int("diff-context", diff.DefaultContext, "Number of context lines shown in dry-run diffs")
+	patchOut = flags.String("patch-out", "", "Write all changes to this git patch file instead of modifying the project")
+	reportOut = flags.String("report", "", "Write a JSON report of every processed file and recipe change to this file")
+	useCache = flags.Bool("cache", true, "Skip files a previous run of the same recipe left unchanged, using "+cache.DirName+" in the project; only runs that write the project use it")
+	jobs = flags.Int("jobs", runtime.NumCPU(), "Number of files to parse and transform in parallel")
+
+	skipGenerated = flags.Bool("skip-generated", true, "Skip build output folders and generated sources")
+	includes, excludes, srcDirs = nil, nil, nil
+	flags.Var(&srcDirs, "src", "Source directory to scan in each module, repeatable (default src/main/java and src/test/java)")
+	flags.Var(&includes, "include", "Only process files matching this glob (repeatable)")
+	flags.Var(&excludes, "exclude", "Skip files matching this glob (repeatable)")
+
+	configPath = flags.String("config", "", "Project config file (default rewrite.yml or .rewrite.yml in the project root)")
+
+	since = flags.String("since", "", "Only edit Java and build files changed since this git ref; other files are still scanned")
+
+	interactive = flags.Bool("interactive", false, "Review every proposed hunk and only apply the accepted ones")
+
+	commitEach = flags.Bool("commit-per-recipe", false, "Apply each recipe in turn and create a git commit for its changes")
+
+	failOnChange = flags.Bool("fail-on-change", false, "Run as a dry run and exit with status 3 if any recipe would change a file")
+	format = flags.String("format", "text", "Output format: text, or sarif to print a SARIF 2.1.0 log of the changes without applying them")
+
+	defineLoggingFlags(flags)
+}
+
+// toolName identifies this tool in machine-readable output
+const toolName = "rewrite-migrate-java"
+
+// toolInformationURI identifies this tool in machine-readable output
+const toolInformationURI = "https://github.com/openrewrite/rewrite-migrate-java"
+
+// toolVersion identifies this build so cached results do not outlive it
+func toolVersion() string {
+	info, ok := debug.ReadBuildInfo()
+	if !ok {
+		return "unknown"
+	}
+	version := info.Main.Version
+	for _, setting := range info.Settings {
+		switch {
+		case setting.Key == "vcs.revision":
+			version += "+" + setting.Value
+		case setting.Key == "vcs.modified" && setting.Value == "true":
+			version += "-dirty"
+		}
 	}
+	return version
+}
 
-	fmt.Printf("Starting Java migration to version %d\n", *version)
-	fmt.Printf("Recipe: %s\n", migrationRecipe.GetDisplayName())
-	fmt.Printf("Description: %s\n", migrationRecipe.GetDescription())
-	fmt.Printf("Estimated effort: %v\n\n", migrationRecipe.GetEstimatedEffortPerOccurrence())
+// exitCodeChanges is the exit status of check mode when recipes would change files
+const exitCodeChanges = 3
 
-	// Find and process files
-	err := processProject(projectPath, migrationRecipe)
-	if err != nil {
-		log.Fatalf("Migration failed: %v", err)
+// stringList is a flag.Value collecting repeated or comma-separated values
+type stringList []string
+
+func (l *stringList) String() string {
+	return strings.Join(*l, ",")
+}
+
+func (l *stringList) Set(value string) error {
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			*l = append(*l, item)
+		}
 	}
+	return nil
+}
 
-	fmt.Println("Migration completed successfully!")
+func main() {
+	os.Exit(run(os.Args[1:], os.Stdout, os.Stderr))
 }
 
-func processProject(projectPath string, migrationRecipe recipe.Recipe) error {
-	ctx := &recipe.ExecutionContext{
-		Context:    context.Background(),
-		Properties: make(map[string]interface{}),
+// run runs the command line args, writing output to stdout and logs to stderr, and returns
+// the exit status
+func run(args []string, stdout, stderr io.Writer) int {
+	out, logOutput = stdout, stderr
+	reviewer = nil
+	logger = slog.New(slog.NewTextHandler(stderr, nil))
+
+	if len(args) > 0 && args[0] == "undo" {
+		return undo(args[1:], stderr)
+	}
+	if len(args) > 0 && args[0] == "lsp" {
+		return serveLSP(stdout)
+	}
+	if len(args) > 0 && args[0] == "serve" {
+		return serve(args[1:], stderr)
+	}
+
+	flags := flag.NewFlagSet(os.Args[0], flag.ContinueOnError)
+	flags.SetOutput(stderr)
+	defineFlags(flags)
+
+	checkMode := len(args) > 0 && args[0] == "check"
+	if checkMode || len(args) > 0 && args[0] == "run" {
+		args = args[1:]
+	}
+	if err := flags.Parse(args); err != nil {
+		return 1
+	}
+	if checkMode {
+		*failOnChange = true
+	}
+
+	if flags.NArg() < 1 {
+		fmt.Fprintf(stderr, "Usage: %s [run|check] [options] <project-path>\n       %s undo <project-path>\n       %s lsp\n       %s serve [-addr :8080]\n", os.Args[0], os.Args[0], os.Args[0], os.Args[0])
+		flags.PrintDefaults()
+		return 1
 	}
 
-	visitor := migrationRecipe.GetVisitor()
-	if visitor == nil {
-		return fmt.Errorf("no visitor available for recipe")
+	if err := setupLogging(stderr); err != nil {
+		return failed("Invalid logging options", "error", err)
 	}
 
-	// Process Java source files
-	sourceDir := filepath.Join(projectPath, *srcDir)
-	err := processSourceFiles(sourceDir, visitor, ctx)
+	projectPath := flags.Arg(0)
+
+	cfg, cfgDir, err := loadConfig(projectPath)
 	if err != nil {
-		return fmt.Errorf("failed to process source files: %w", err)
+		return failed("Invalid configuration", "error", err)
+	}
+	applyConfig(cfg, cfgDir, flags)
+	if *failOnChange {
+		*dryRun = true
+	}
+	switch *format {
+	case "text":
+	case "sarif":
+		out = stderr
+		*dryRun = true
+	default:
+		return failed("Unsupported format, expected text or sarif", "format", *format)
+	}
+
+	if *commitEach && (*dryRun || *patchOut != "" || *reportOut != "") {
+		return failed("-commit-per-recipe writes changes to the project and cannot be combined with dry runs, patches, reports or check mode")
+	}
+
+	if *interactive {
+		reviewer = newHunkReviewer(stdin)
 	}
 
-	// Process build files
-	err = processBuildFiles(projectPath, visitor, ctx)
+	migrationRecipe, err := selectRecipe(cfg)
 	if err != nil {
-		return fmt.Errorf("failed to process build files: %w", err)
+		return failed("Invalid configuration", "error", err)
 	}
 
-	return nil
-}
+	attrs := []interface{}{"recipe", migrationRecipe.GetDisplayName()}
+	if len(cfg.Recipes) == 0 {
+		attrs = append(attrs, "javaVersion", *version)
+	} else {
+		attrs = append(attrs, "configuredRecipes", len(cfg.Recipes))
+	}
+	attrs = append(attrs, "estimatedEffort", migrationRecipe.GetEstimatedEffortPerOccurrence().String())
+	logger.Info("Starting migration", attrs...)
+	logger.Debug("Recipe description", "description", migrationRecipe.GetDescription())
 
-func processSourceFiles(sourceDir string, visitor recipe.TreeVisitor, ctx *recipe.ExecutionContext) error {
-	return filepath.WalkDir(sourceDir, func(path string, d fs.DirEntry, err error) error {
-		if err != nil {
-			if os.IsNotExist(err) {
-				return nil // Skip if source directory doesn't exist
-			}
-			return err
+	if *commitEach {
+		if err := commitPerRecipe(projectPath, migrationRecipe, journal.Begin(projectPath, migrationRecipe.GetName())); err != nil {
+			return failed("Migration failed", "error", err)
 		}
+		logger.Info("Migration completed")
+		return 0
+	}
+
+	var runReport *report.Report
+	if *reportOut != "" {
+		runReport = report.New(toolName, projectPath, migrationRecipe, *dryRun || *patchOut != "")
+	}
 
-		if d.IsDir() || !strings.HasSuffix(path, ".java") {
-			return nil
+	// Find and process files
+	runJournal := journal.Begin(projectPath, migrationRecipe.GetName())
+	changes, err := processProject(projectPath, migrationRecipe, runReport, runJournal)
+	if runReport != nil {
+		runReport.Finish()
+		if err := runReport.WriteFile(*reportOut); err != nil {
+			return failed("Failed to write report", "error", err)
 		}
+		logger.Info("Report written", "path", *reportOut)
+	}
+	if err != nil {
+		return failed("Migration failed", "error", err)
+	}
 
-		return processFile(path, visitor, ctx)
-	})
-}
+	if *patchOut != "" {
+		patch := diff.GitPatch(fileChanges(changes), *diffContext)
+		if err := os.WriteFile(*patchOut, []byte(patch), 0644); err != nil {
+			return failed("Failed to write patch", "path", *patchOut, "error", err)
+		}
+		logger.Info("Patch written", "path", *patchOut, "files", len(changes))
+	}
 
-func processBuildFiles(projectPath string, visitor recipe.TreeVisitor, ctx *recipe.ExecutionContext) error {
-	buildFiles := []string{
-		"pom.xml",
-		"build.gradle",
-		"build.gradle.kts",
+	if *format == "sarif" {
+		if err := writeSarif(stdout, migrationRecipe, changes); err != nil {
+			return failed("Failed to write SARIF output", "error", err)
+		}
 	}
 
-	for _, buildFile := range buildFiles {
-		buildPath := filepath.Join(projectPath, buildFile)
-		if _, err := os.Stat(buildPath); err == nil {
-			err = processFile(buildPath, visitor, ctx)
-			if err != nil {
-				return err
-			}
+	if *failOnChange && len(changes) > 0 {
+		for _, change := range changes {
+			logger.Warn("File would be changed by the migration recipes", "path", change.Path)
 		}
+		logger.Error("Check failed", "files", len(changes))
+		return exitCodeChanges
 	}
 
-	return nil
+	logger.Info("Migration completed", "files", len(changes))
+	return 0
 }
 
-func processFile(path string, visitor recipe.TreeVisitor, ctx *recipe.ExecutionContext) error {
-	fmt.Printf("Processing: %s\n", path)
-
-	content, err := os.ReadFile(path)
-	if err != nil {
-		return fmt.Errorf("failed to read file %s: %w", path, err)
+// processProject runs the recipe over every module of the project, recording each file in runReport if it is
+// not nil. Files are written through runJournal so the run can be undone, and only once every file has
+// been processed: when any file fails, the failures are reported and no file is written.
+func processProject(projectPath string, migrationRecipe recipe.Recipe, runReport *report.Report, runJournal *journal.Journal) ([]runner.Result, error) {
+	ctx := recipe.NewExecutionContext(context.Background())
+	ctx.SetLogger(logger)
+
+	if info, err := os.Stat(projectPath); err != nil {
+		return nil, fmt.Errorf("failed to read project %s: %w", projectPath, err)
+	} else if !info.IsDir() {
+		return nil, fmt.Errorf("project path %s is not a directory", projectPath)
 	}
 
-	var sourceFile recipe.SourceFile
-	if strings.HasSuffix(path, ".java") {
-		sourceFile, err = java.NewJavaSourceFile(path, string(content))
+	// Every file is still visited so recipes see the whole project, but only changed files are edited
+	var changedFiles map[string]bool
+	if *since != "" {
+		var err error
+		changedFiles, err = project.ChangedFiles(projectPath, *since)
 		if err != nil {
-			return fmt.Errorf("failed to parse Java file %s: %w", path, err)
-		}
-	} else {
-		// For build files, create a simple wrapper
-		sourceFile = &SimpleSourceFile{
-			path:    path,
-			content: string(content),
+			return nil, fmt.Errorf("failed to list files changed since %s: %w", *since, err)
 		}
+		logger.Info("Limiting edits to files changed since ref", "ref", *since, "files", len(changedFiles))
+	}
+
+	opts := runner.Options{
+		SourceDirs:    srcDirs,
+		Includes:      includes,
+		Excludes:      excludes,
+		SkipGenerated: *skipGenerated,
+		Jobs:          *jobs,
+	}
+	// Runs that do not write the project leave no cache behind either
+	if *useCache && !*dryRun && *patchOut == "" {
+		opts.Cache = cache.Open(filepath.Join(projectPath, cache.DirName), toolVersion(), migrationRecipe)
 	}
 
-	// Apply transformations
-	transformedFile, err := visitor.Visit(sourceFile, ctx)
+	var progress *progressLine
+	if *quiet {
+		progress = newProgressLine(logOutput, summaryLogger)
+		opts.Progress = progress.update
+	}
+
+	results, err := runner.Run(ctx, migrationRecipe, os.DirFS(projectPath), opts)
+	if progress != nil {
+		progress.finish()
+	}
 	if err != nil {
-		return fmt.Errorf("failed to transform file %s: %w", path, err)
+		return nil, err
+	}
+
+	// Changes are only reviewed and written when every file could be processed
+	failed := false
+	for _, result := range results {
+		failed = failed || result.Err != nil
+	}
+	if failed && runReport != nil {
+		// The changes the report lists are then not written
+		runReport.DryRun = true
 	}
 
-	// Check if file was modified
-	if transformedFile.GetContent() != sourceFile.GetContent() {
-		fmt.Printf("  → Modified\n")
+	var changes []runner.Result
+	var failures []error
+	cached := 0
+	var summaries []moduleSummary
+	for _, result := range results {
+		logger.Debug("Processing file", "path", result.Path, "module", result.Module)
+
+		// Results are grouped by module
+		if len(summaries) == 0 || summaries[len(summaries)-1].path != result.Module {
+			summaries = append(summaries, moduleSummary{path: result.Module})
+		}
+		summary := &summaries[len(summaries)-1]
+
+		if result.Err != nil {
+			logger.Error("Failed to process file", "path", result.Path, "module", result.Module, "error", result.Err)
+			summary.failed++
+			failures = append(failures, result.Err)
+			if runReport != nil {
+				runReport.Add(report.File{Path: result.Path, Module: result.Module, Status: report.StatusError, Error: result.Err.Error()})
+			}
+			continue
+		}
+
+		if result.Skipped {
+			logger.Info("Skipped file", "path", result.Path, "reason", "generated source")
+			summary.skipped++
+			if runReport != nil {
+				runReport.Add(report.File{Path: result.Path, Module: result.Module, Status: report.StatusSkipped, SkipReason: "generated source"})
+			}
+			continue
+		}
+
+		if result.Cached {
+			cached++
+		}
 
-		if *dryRun {
-			fmt.Printf("  → Would write changes (dry-run mode)\n")
-		} else {
-			err = os.WriteFile(path, []byte(transformedFile.GetContent()), 0644)
+		if result.Changed() && changedFiles != nil && !changedFiles[result.Path] {
+			logger.Debug("Skipped file", "path", result.Path, "reason", "not changed since "+*since)
+			summary.skipped++
+			if runReport != nil {
+				runReport.Add(report.File{Path: result.Path, Module: result.Module, Status: report.StatusSkipped, SkipReason: "not changed since " + *since})
+			}
+			continue
+		}
+
+		var decisions []report.Decision
+		if reviewer != nil && result.Changed() && !failed {
+			decisions, err = reviewer.review(&result, ctx)
 			if err != nil {
-				return fmt.Errorf("failed to write file %s: %w", path, err)
+				return nil, err
 			}
-			fmt.Printf("  → Changes written\n")
 		}
+
+		if runReport != nil {
+			file := report.NewFile(result.Path, result.Module, result.Duration, result.RecipeResults)
+			file.Decisions = decisions
+			runReport.Add(file)
+		}
+
+		summary.processed++
+		if !result.Changed() {
+			continue
+		}
+		summary.changed++
+		changes = append(changes, result)
 	}
 
-	return nil
-}
+	logModuleSummaries(summaries)
+	if cached > 0 {
+		logger.Info("Skipped unchanged files using the result cache", "files", cached)
+	}
+	// A failed file leaves the whole project as it was rather than half migrated
+	if len(failures) > 0 {
+		return nil, fmt.Errorf("failed to process %d files, no file was changed: %w", len(failures), errors.Join(failures...))
+	}
 
-// SimpleSourceFile implements recipe.SourceFile for non-Java files
-type SimpleSourceFile struct {
-	path    string
-	content string
+	for _, change := range changes {
+		if err := applyChange(filepath.Join(projectPath, filepath.FromSlash(change.Path)), change, runJournal); err != nil {
+			return nil, err
+		}
+	}
+	return changes, nil
 }
 
-func (s *SimpleSourceFile) GetPath() string {
-	return s.path
+// moduleSummary counts the files processed and changed in a single module
+type moduleSummary struct {
+	path      string
+	processed int
+	changed   int
+	skipped   int
+	failed    int
 }
 
-func (s *SimpleSourceFile) GetContent() string {
-	return s.content
+func logModuleSummaries(summaries []moduleSummary) {
+	for _, summary := range summaries {
+		logger.Info("Module summary", "module", summary.path,
+			"processed", summary.processed, "changed", summary.changed, "skipped", summary.skipped, "failed", summary.failed)
+	}
 }
 
-func (s *SimpleSourceFile) GetClasses() []recipe.ClassDeclaration {
+// applyChange reports a change and writes it to disk unless running in dry-run or patch mode
+func applyChange(path string, change runner.Result, runJournal *journal.Journal) error {
+	var recipes []string
+	seen := make(map[string]bool)
+	for _, result := range change.RecipeResults {
+		if name := result.Recipe.GetName(); result.Changed() && !seen[name] {
+			seen[name] = true
+			recipes = append(recipes, name)
+		}
+	}
+
+	switch {
+	case *dryRun:
+		logger.Info("File would be changed", "path", change.Path, "recipes", recipes)
+		printDiff(fileChange(change))
+	case *patchOut != "":
+		logger.Info("File change added to patch", "path", change.Path, "recipes", recipes)
+	default:
+		if err := runJournal.Write(path, []byte(change.Before), []byte(change.After), change.Format.Mode); err != nil {
+			return err
+		}
+		logger.Info("File changed", "path", change.Path, "recipes", recipes)
+	}
+
 	return nil
 }
 
-func (s *SimpleSourceFile) GetImports() []recipe.ImportDeclaration {
-	return nil
+// writeSarif prints a SARIF log to w with every recipe as a rule and every change as a result
+func writeSarif(w io.Writer, migrationRecipe recipe.Recipe, changes []runner.Result) error {
+	sarifLog := sarif.NewLog(toolName, toolInformationURI)
+	run := sarifLog.Runs[0]
+	for _, leaf := range recipe.Leaves(migrationRecipe) {
+		run.AddRule(leaf)
+	}
+	for _, change := range changes {
+		run.AddChanges(change.Path, change.RecipeResults)
+	}
+	return sarifLog.Write(w)
+}
+
+// fileChange returns the plain file change of a changed file
+func fileChange(result runner.Result) diff.FileChange {
+	return diff.FileChange{Path: result.Path, Before: result.Before, After: result.After}
+}
+
+// fileChanges returns the plain file changes of changed files
+func fileChanges(changes []runner.Result) []diff.FileChange {
+	result := make([]diff.FileChange, len(changes))
+	for i, change := range changes {
+		result[i] = fileChange(change)
+	}
+	return result
+}
+
+// printDiff writes a unified diff of a pending change to out
+func printDiff(change diff.FileChange) {
+	unified := change.Unified(*diffContext)
+	if colorEnabled(out) {
+		unified = diff.Colorize(unified)
+	}
+	fmt.Fprint(out, unified)
 }
 
-func (s *SimpleSourceFile) GetPackage() string {
-	return ""
+// colorEnabled reports whether w is a terminal that shows colors
+func colorEnabled(w io.Writer) bool {
+	f, ok := w.(*os.File)
+	return ok && diff.ColorEnabled(f)
 }
 
-func (s *SimpleSourceFile) WithContent(content string) recipe.SourceFile {
-	return &SimpleSourceFile{
-		path:    s.path,
-		content: content,
+// isTerminal reports whether w is a terminal, whether or not it shows colors
+func isTerminal(w io.Writer) bool {
+	f, ok := w.(*os.File)
+	if !ok {
+		return false
 	}
+	info, err := f.Stat()
+	return err == nil && info.Mode()&os.ModeCharDevice != 0
 }
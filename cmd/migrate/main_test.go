@@ -0,0 +1,422 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"io/fs"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"rewrite-migrate-java/pkg/config"
+)
+
+const base64Source = `package com.example;
+
+import sun.misc.BASE64Encoder;
+
+public class Example {
+    public String encode(byte[] data) {
+        return new BASE64Encoder().encode(data);
+    }
+}
+`
+
+const java8Pom = `<project>
+  <properties>
+    <maven.compiler.source>8</maven.compiler.source>
+  </properties>
+</project>
+`
+
+func writeProject(t *testing.T, files map[string]string) string {
+	t.Helper()
+	root := t.TempDir()
+	for name, content := range files {
+		path := filepath.Join(root, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return root
+}
+
+func readFile(t *testing.T, root, name string) string {
+	t.Helper()
+	content, err := os.ReadFile(filepath.Join(root, filepath.FromSlash(name)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(content)
+}
+
+// snapshot returns the content of every file below root, keyed by slash-separated path
+func snapshot(t *testing.T, root string) map[string]string {
+	t.Helper()
+	files := make(map[string]string)
+	err := filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() {
+			return err
+		}
+		rel, err := filepath.Rel(root, path)
+		if err != nil {
+			return err
+		}
+		files[filepath.ToSlash(rel)] = readFile(t, root, filepath.ToSlash(rel))
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return files
+}
+
+// runMigrate runs the command line and returns its exit status and logs
+func runMigrate(t *testing.T, args ...string) (int, string) {
+	t.Helper()
+	var stdout, stderr bytes.Buffer
+	status := run(args, &stdout, &stderr)
+	return status, stderr.String()
+}
+
+func TestCheckExitStatus(t *testing.T) {
+	tests := []struct {
+		name     string
+		pom      string
+		expected int
+	}{
+		{"needs migration", java8Pom, exitCodeChanges},
+		{"already migrated", strings.Replace(java8Pom, ">8<", ">17<", 1), 0},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			root := writeProject(t, map[string]string{"pom.xml": test.pom})
+			status, logs := runMigrate(t, "check", "-version", "17", root)
+			if status != test.expected {
+				t.Fatalf("Expected exit status %d, got %d\n%s", test.expected, status, logs)
+			}
+			if got := readFile(t, root, "pom.xml"); got != test.pom {
+				t.Errorf("Expected check mode to leave pom.xml unchanged, got:\n%s", got)
+			}
+		})
+	}
+}
+
+func TestRunsWithoutWritesLeaveProjectUntouched(t *testing.T) {
+	source := "src/main/java/com/example/Example.java"
+	tests := map[string][]string{
+		"check":     {"check"},
+		"dry run":   {"-dry-run"},
+		"patch out": {"-patch-out", filepath.Join(t.TempDir(), "migration.patch")},
+		"sarif":     {"-format", "sarif"},
+	}
+
+	for name, args := range tests {
+		t.Run(name, func(t *testing.T) {
+			root := writeProject(t, map[string]string{
+				"pom.xml":                  java8Pom,
+				source:                     base64Source,
+				"src/main/java/Plain.java": "class Plain {}\n",
+			})
+			before := snapshot(t, root)
+			runMigrate(t, append(args, root)...)
+			if after := snapshot(t, root); !reflect.DeepEqual(after, before) {
+				t.Errorf("Expected the project to stay byte-identical, got files %v", after)
+			}
+		})
+	}
+}
+
+func TestConfigAndFlagPrecedence(t *testing.T) {
+	tests := []struct {
+		name     string
+		config   string
+		args     []string
+		expected string
+	}{
+		{"config version", "version: 11\n", nil, ">11<"},
+		{"flag overrides config version", "version: 11\n", []string{"-version", "17"}, ">17<"},
+		{"config dry run", "version: 11\noutput:\n  dryRun: true\n", nil, ">8<"},
+		{"flag overrides config dry run", "version: 11\noutput:\n  dryRun: true\n", []string{"-dry-run=false"}, ">11<"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			root := writeProject(t, map[string]string{"pom.xml": java8Pom, "rewrite.yml": test.config})
+			if status, logs := runMigrate(t, append(test.args, root)...); status != 0 {
+				t.Fatalf("Expected exit status 0, got %d\n%s", status, logs)
+			}
+			if got := readFile(t, root, "pom.xml"); !strings.Contains(got, test.expected) {
+				t.Errorf("Expected pom.xml to contain %s, got:\n%s", test.expected, got)
+			}
+		})
+	}
+}
+
+func TestConfigOutputPathsAreRelativeToConfig(t *testing.T) {
+	config := "output:\n  patchOut: migration.patch\n  report: report.json\n"
+	root := writeProject(t, map[string]string{"pom.xml": java8Pom, "rewrite.yml": config})
+	elsewhere := writeProject(t, map[string]string{"shared.yml": config})
+
+	if status, logs := runMigrate(t, root); status != 0 {
+		t.Fatalf("Migration failed with status %d\n%s", status, logs)
+	}
+	if status, logs := runMigrate(t, "-config", filepath.Join(elsewhere, "shared.yml"), root); status != 0 {
+		t.Fatalf("Migration failed with status %d\n%s", status, logs)
+	}
+
+	for _, dir := range []string{root, elsewhere} {
+		for _, name := range []string{"migration.patch", "report.json"} {
+			if _, err := os.Stat(filepath.Join(dir, name)); err != nil {
+				t.Errorf("Expected %s next to the config file: %v", name, err)
+			}
+		}
+	}
+	if _, err := os.Stat("migration.patch"); err == nil {
+		os.Remove("migration.patch")
+		t.Error("Expected no patch in the working directory")
+	}
+}
+
+func TestConfiguredRecipesHaveStableName(t *testing.T) {
+	cfg := &config.Config{Recipes: []config.RecipeConfig{
+		{Name: "org.openrewrite.java.migrate.UseJavaUtilBase64"},
+		{Name: "org.openrewrite.java.migrate.jakarta.JavaxMigrationToJakarta"},
+	}}
+	r, err := selectRecipe(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if r.GetName() != configuredRecipesName {
+		t.Errorf("Expected the configured recipes to be named %s, got %q", configuredRecipesName, r.GetName())
+	}
+}
+
+func TestUndo(t *testing.T) {
+	source := "src/main/java/com/example/Example.java"
+	root := writeProject(t, map[string]string{"pom.xml": java8Pom, source: base64Source})
+
+	if status, logs := runMigrate(t, "-version", "17", root); status != 0 {
+		t.Fatalf("Migration failed with status %d\n%s", status, logs)
+	}
+	if readFile(t, root, source) == base64Source {
+		t.Fatal("Expected the migration to change Example.java")
+	}
+
+	if status, logs := runMigrate(t, "undo", root); status != 0 {
+		t.Fatalf("Undo failed with status %d\n%s", status, logs)
+	}
+	if readFile(t, root, source) != base64Source || readFile(t, root, "pom.xml") != java8Pom {
+		t.Error("Expected undo to restore every file")
+	}
+}
+
+func TestInteractiveReview(t *testing.T) {
+	source := "src/main/java/com/example/Example.java"
+	tests := []struct {
+		answers string
+		changed bool
+	}{
+		{"n\n", false},
+		{"y\n", true},
+		{"q\n", false},
+		{"", false},
+	}
+
+	defer func() { stdin = os.Stdin }()
+	for _, test := range tests {
+		root := writeProject(t, map[string]string{source: base64Source})
+		stdin = strings.NewReader(test.answers)
+		if status, logs := runMigrate(t, "-interactive", root); status != 0 {
+			t.Fatalf("Migration failed with status %d\n%s", status, logs)
+		}
+		if changed := readFile(t, root, source) != base64Source; changed != test.changed {
+			t.Errorf("Answering %q: expected changed=%v, got %v", test.answers, test.changed, changed)
+		}
+	}
+}
+
+func TestInteractiveReviewOfChainedRecipes(t *testing.T) {
+	source := "src/main/java/com/example/Example.java"
+	tests := []struct {
+		answers     string
+		javaChanged bool
+		pomVersion  string
+	}{
+		// The Java file is reviewed first, then the net Java version upgrade of the POM once
+		{"n\ny\n", false, ">17<"},
+		{"y\nn\n", true, ">8<"},
+		// Accepting all from one recipe leaves the others to ask about
+		{"a\n", true, ">8<"},
+	}
+
+	defer func() { stdin = os.Stdin }()
+	for _, test := range tests {
+		root := writeProject(t, map[string]string{"pom.xml": java8Pom, source: base64Source})
+		stdin = strings.NewReader(test.answers)
+		var stdout, stderr bytes.Buffer
+		if status := run([]string{"-interactive", "-version", "17", root}, &stdout, &stderr); status != 0 {
+			t.Fatalf("Migration failed with status %d\n%s", status, stderr.String())
+		}
+
+		if prompts := strings.Count(stdout.String(), "Apply this hunk?"); prompts != 2 {
+			t.Errorf("Answering %q: expected 2 questions, got %d:\n%s", test.answers, prompts, stdout.String())
+		}
+		if changed := readFile(t, root, source) != base64Source; changed != test.javaChanged {
+			t.Errorf("Answering %q: expected the Java file changed=%v, got %v", test.answers, test.javaChanged, changed)
+		}
+		if pom := readFile(t, root, "pom.xml"); !strings.Contains(pom, test.pomVersion) {
+			t.Errorf("Answering %q: expected pom.xml to contain %s, got:\n%s", test.answers, test.pomVersion, pom)
+		}
+	}
+}
+
+func runGit(t *testing.T, dir string, args ...string) {
+	t.Helper()
+	cmd := exec.Command("git", append([]string{"-C", dir}, args...)...)
+	cmd.Env = append(os.Environ(),
+		"GIT_AUTHOR_NAME=test", "GIT_AUTHOR_EMAIL=test@example.com",
+		"GIT_COMMITTER_NAME=test", "GIT_COMMITTER_EMAIL=test@example.com")
+	if output, err := cmd.CombinedOutput(); err != nil {
+		t.Fatalf("git %v failed: %v\n%s", args, err, output)
+	}
+}
+
+func TestSince(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git is not installed")
+	}
+
+	old, edited := "src/main/java/com/example/Old.java", "src/main/java/com/example/Edited.java"
+	root := writeProject(t, map[string]string{old: base64Source, edited: base64Source})
+	runGit(t, root, "init", "-q")
+	runGit(t, root, "add", "-A")
+	runGit(t, root, "commit", "-q", "-m", "initial")
+
+	editedSource := base64Source + "// edited\n"
+	if err := os.WriteFile(filepath.Join(root, filepath.FromSlash(edited)), []byte(editedSource), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	if status, logs := runMigrate(t, "-since", "HEAD", "-cache=false", root); status != 0 {
+		t.Fatalf("Migration failed with status %d\n%s", status, logs)
+	}
+	if readFile(t, root, old) != base64Source {
+		t.Error("Expected a file unchanged since HEAD to be left alone")
+	}
+	if readFile(t, root, edited) == editedSource {
+		t.Error("Expected a file changed since HEAD to be migrated")
+	}
+}
+
+func TestCommitPerRecipeOfChainedRecipes(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git is not installed")
+	}
+
+	source := "src/main/java/com/example/Example.java"
+	root := writeProject(t, map[string]string{"pom.xml": java8Pom, source: base64Source})
+	runGit(t, root, "init", "-q")
+	runGit(t, root, "config", "user.name", "test")
+	runGit(t, root, "config", "user.email", "test@example.com")
+	runGit(t, root, "add", "-A")
+	runGit(t, root, "commit", "-q", "-m", "initial")
+
+	if status, logs := runMigrate(t, "-commit-per-recipe", "-version", "17", "-cache=false", root); status != 0 {
+		t.Fatalf("Migration failed with status %d\n%s", status, logs)
+	}
+
+	// The Base64 migration and the build file upgrade nested in the Java 11 migration are
+	// committed separately, and the build file is upgraded straight to Java 17
+	git := func(args ...string) string {
+		output, err := exec.Command("git", append([]string{"-C", root}, args...)...).Output()
+		if err != nil {
+			t.Fatalf("git %v failed: %v", args, err)
+		}
+		return strings.TrimSpace(string(output))
+	}
+	expected := "Upgrade Java version\nPrefer `java.util.Base64` instead of `sun.misc`\ninitial"
+	if subjects := git("log", "--format=%s"); subjects != expected {
+		t.Errorf("Expected commits:\n%s\nGot:\n%s", expected, subjects)
+	}
+	if files := git("show", "--name-only", "--format=", "HEAD"); files != "pom.xml" {
+		t.Errorf("Expected the build file upgrade to commit pom.xml alone, got %s", files)
+	}
+	if pom := readFile(t, root, "pom.xml"); !strings.Contains(pom, ">17<") {
+		t.Errorf("Expected pom.xml to target Java 17, got:\n%s", pom)
+	}
+}
+
+func TestFailedFileLeavesProjectUntouched(t *testing.T) {
+	root := writeProject(t, map[string]string{
+		"pom.xml":                                java8Pom,
+		"src/main/java/com/example/Example.java": base64Source,
+	})
+	// A dangling link is listed like a source file but cannot be read
+	if err := os.Symlink("missing", filepath.Join(root, "src/main/java/com/example/Broken.java")); err != nil {
+		t.Skipf("symbolic links are not supported: %v", err)
+	}
+	reportPath := filepath.Join(t.TempDir(), "report.json")
+
+	status, logs := runMigrate(t, "-version", "17", "-cache=false", "-report", reportPath, root)
+	if status == 0 {
+		t.Fatalf("Expected the migration to fail\n%s", logs)
+	}
+	if readFile(t, root, "pom.xml") != java8Pom || readFile(t, root, "src/main/java/com/example/Example.java") != base64Source {
+		t.Error("Expected no file to be written when a file fails")
+	}
+
+	// Every file is still processed and reported
+	var runReport struct {
+		DryRun  bool `json:"dryRun"`
+		Summary struct {
+			FilesProcessed int `json:"filesProcessed"`
+			FilesFailed    int `json:"filesFailed"`
+		} `json:"summary"`
+	}
+	if err := json.Unmarshal([]byte(readFile(t, filepath.Dir(reportPath), "report.json")), &runReport); err != nil {
+		t.Fatal(err)
+	}
+	if runReport.Summary.FilesFailed != 1 || runReport.Summary.FilesProcessed != 2 {
+		t.Errorf("Expected 2 processed files and 1 failure in the report, got %+v", runReport.Summary)
+	}
+	if !runReport.DryRun {
+		t.Error("Expected the report to mark the changes as not written")
+	}
+	if !strings.Contains(logs, "failed=1") {
+		t.Errorf("Expected the module summary to count the failure, got:\n%s", logs)
+	}
+}
+
+func TestQuietJSONLogsSummaryRecord(t *testing.T) {
+	root := writeProject(t, map[string]string{"src/main/java/com/example/Example.java": base64Source})
+
+	status, logs := runMigrate(t, "-q", "-log-format", "json", "-cache=false", root)
+	if status != 0 {
+		t.Fatalf("Migration failed with status %d\n%s", status, logs)
+	}
+
+	var summaries int
+	for _, line := range strings.Split(strings.TrimSpace(logs), "\n") {
+		var record map[string]interface{}
+		if err := json.Unmarshal([]byte(line), &record); err != nil {
+			t.Fatalf("Expected only JSON records, got %q", line)
+		}
+		if record["msg"] == "Scanned files" {
+			summaries++
+			if record["changed"] != 1.0 || record["total"] != 1.0 {
+				t.Errorf("Unexpected summary record %v", record)
+			}
+		}
+	}
+	if summaries != 1 {
+		t.Errorf("Expected one summary record, got %d:\n%s", summaries, logs)
+	}
+}
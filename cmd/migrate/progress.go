@@ -0,0 +1,75 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"log/slog"
+	"time"
+
+	"rewrite-migrate-java/pkg/runner"
+)
+
+// progressLine shows how many files were scanned, changed and failed, and the estimated time
+// left, on a single line. It is redrawn in place on terminals and only printed once done
+// otherwise, so that logs of CI jobs stay short. With JSON logs, the final state is logged
+// through summary instead.
+type progressLine struct {
+	w       io.Writer
+	summary *slog.Logger
+	live    bool
+	start   time.Time
+
+	done    int
+	total   int
+	changed int
+	failed  int
+}
+
+func newProgressLine(w io.Writer, summary *slog.Logger) *progressLine {
+	return &progressLine{
+		w:       w,
+		summary: summary,
+		live:    summary == nil && isTerminal(w),
+		start:   time.Now(),
+	}
+}
+
+// update records a finished file; it is a runner.Options.Progress callback
+func (p *progressLine) update(result runner.Result, done, total int) {
+	p.done, p.total = done, total
+	switch {
+	case result.Err != nil:
+		p.failed++
+	case result.Changed():
+		p.changed++
+	}
+
+	if p.live {
+		fmt.Fprintf(p.w, "\r%s\x1b[K", p.status())
+	}
+}
+
+// finish prints the final state of the line
+func (p *progressLine) finish() {
+	if p.summary != nil {
+		p.summary.Info("Scanned files", "scanned", p.done, "total", p.total,
+			"changed", p.changed, "failed", p.failed, "duration", time.Since(p.start).Round(time.Millisecond).String())
+		return
+	}
+
+	line := fmt.Sprintf("Scanned %d/%d files, %d changed, %d failed in %v",
+		p.done, p.total, p.changed, p.failed, time.Since(p.start).Round(time.Millisecond))
+	if p.live {
+		line = "\r" + line + "\x1b[K"
+	}
+	fmt.Fprintln(p.w, line)
+}
+
+func (p *progressLine) status() string {
+	eta := "unknown"
+	if p.done > 0 {
+		elapsed := time.Since(p.start)
+		eta = (elapsed / time.Duration(p.done) * time.Duration(p.total-p.done)).Round(time.Second).String()
+	}
+	return fmt.Sprintf("Scanned %d/%d files, %d changed, %d failed, ETA %s", p.done, p.total, p.changed, p.failed, eta)
+}
@@ -0,0 +1,38 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+
+	"rewrite-migrate-java/pkg/journal"
+)
+
+// undo restores the files written by the last migration run over the project in args and
+// returns the exit status
+func undo(args []string, stderr io.Writer) int {
+	if len(args) != 1 {
+		fmt.Fprintf(stderr, "Usage: %s undo <project-path>\n", os.Args[0])
+		return 1
+	}
+
+	run, err := journal.Undo(args[0])
+	var modified *journal.ModifiedError
+	if errors.As(err, &modified) {
+		return failed("Refusing to undo, files were edited after the last run", "paths", modified.Paths)
+	}
+	if err != nil {
+		return failed("Undo failed", "error", err)
+	}
+
+	logger.Info("Undid run", "run", run.ID, "recipe", run.Recipe)
+	restored := make(map[string]bool)
+	for _, entry := range run.Files {
+		if !restored[entry.Path] {
+			restored[entry.Path] = true
+			logger.Info("Restored file", "path", entry.Path)
+		}
+	}
+	return 0
+}
@@ -0,0 +1,63 @@
+package cache
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"rewrite-migrate-java/pkg/project"
+	"rewrite-migrate-java/pkg/recipe"
+)
+
+// DirName is the default cache directory, relative to the project root
+const DirName = ".rewrite-cache"
+
+// Cache records which file contents a recipe left unchanged, so later runs can skip them.
+// Entries are keyed by the content hash, the file name, the recipe with all of its
+// options, and the tool version; changing any of them misses the cache.
+type Cache struct {
+	dir  string
+	salt []byte
+}
+
+// Open returns the cache for runs of r by the given tool version, stored below dir
+func Open(dir, toolVersion string, r recipe.Recipe) *Cache {
+	salt := sha256.New()
+	fmt.Fprintf(salt, "%s\x00%s", toolVersion, recipe.Identity(r))
+	return &Cache{dir: dir, salt: salt.Sum(nil)}
+}
+
+// Unchanged reports whether the recipe is known to leave content of the named file unchanged
+func (c *Cache) Unchanged(path string, content []byte) bool {
+	_, err := os.Stat(c.entry(path, content))
+	return err == nil
+}
+
+// MarkUnchanged records that the recipe leaves content of the named file unchanged
+func (c *Cache) MarkUnchanged(path string, content []byte) error {
+	entry := c.entry(path, content)
+	if err := project.IgnoreDir(c.dir); err != nil {
+		return fmt.Errorf("failed to create cache directory: %w", err)
+	}
+	if err := os.MkdirAll(filepath.Dir(entry), 0755); err != nil {
+		return fmt.Errorf("failed to create cache directory: %w", err)
+	}
+	if err := os.WriteFile(entry, nil, 0644); err != nil {
+		return fmt.Errorf("failed to write cache entry: %w", err)
+	}
+	return nil
+}
+
+// entry returns the path of the cache entry for content of the named file. Only the base
+// name takes part in the key, as it decides how the file is parsed.
+func (c *Cache) entry(path string, content []byte) string {
+	hash := sha256.New()
+	hash.Write(c.salt)
+	fmt.Fprintf(hash, "%s\x00", filepath.Base(path))
+	hash.Write(content)
+
+	key := hex.EncodeToString(hash.Sum(nil))
+	return filepath.Join(c.dir, key[:2], key[2:])
+}
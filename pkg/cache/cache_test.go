@@ -0,0 +1,62 @@
+package cache
+
+import (
+	"testing"
+
+	"rewrite-migrate-java/pkg/recipe"
+)
+
+type optionRecipe struct {
+	*recipe.BaseRecipe
+	Version int
+}
+
+func (r *optionRecipe) GetVisitor() recipe.TreeVisitor {
+	return nil
+}
+
+func newOptionRecipe(version int) *optionRecipe {
+	return &optionRecipe{BaseRecipe: &recipe.BaseRecipe{Name: "upgrade"}, Version: version}
+}
+
+func TestCacheKeys(t *testing.T) {
+	dir := t.TempDir()
+	content := []byte("class A {}")
+
+	c := Open(dir, "1.0", newOptionRecipe(17))
+	if c.Unchanged("src/A.java", content) {
+		t.Fatal("Expected an empty cache")
+	}
+	if err := c.MarkUnchanged("src/A.java", content); err != nil {
+		t.Fatalf("MarkUnchanged failed: %v", err)
+	}
+
+	if !c.Unchanged("other/A.java", content) {
+		t.Error("Expected a hit for the same content and file name")
+	}
+	if c.Unchanged("src/A.java", []byte("class A { }")) {
+		t.Error("Expected a miss for different content")
+	}
+	if c.Unchanged("pom.xml", content) {
+		t.Error("Expected a miss for a different file name")
+	}
+
+	tests := []struct {
+		name        string
+		toolVersion string
+		recipe      recipe.Recipe
+		hit         bool
+	}{
+		{"same recipe and version", "1.0", newOptionRecipe(17), true},
+		{"different option", "1.0", newOptionRecipe(21), false},
+		{"different tool version", "1.1", newOptionRecipe(17), false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			reopened := Open(dir, tt.toolVersion, tt.recipe)
+			if hit := reopened.Unchanged("src/A.java", content); hit != tt.hit {
+				t.Errorf("Expected hit=%v, got %v", tt.hit, hit)
+			}
+		})
+	}
+}
@@ -0,0 +1,137 @@
+package config
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// FileNames lists the project configuration files looked up in the project root, in order
+var FileNames = []string{
+	"rewrite.yml",
+	".rewrite.yml",
+}
+
+// Config is the project-level configuration of a migration run
+type Config struct {
+	// Version is the target Java version used when no recipes are listed
+	Version int `yaml:"version"`
+	// Recipes are the active recipes, by registry name
+	Recipes []RecipeConfig `yaml:"recipes"`
+	// Include and Exclude are globs relative to the project root
+	Include []string `yaml:"include"`
+	Exclude []string `yaml:"exclude"`
+	// SourceRoots are the source directories scanned in each module
+	SourceRoots   []string `yaml:"sourceRoots"`
+	SkipGenerated *bool    `yaml:"skipGenerated"`
+	Output        Output   `yaml:"output"`
+}
+
+// Output configures how the results of a run are reported and written
+type Output struct {
+	DryRun      *bool  `yaml:"dryRun"`
+	DiffContext *int   `yaml:"diffContext"`
+	PatchOut    string `yaml:"patchOut"`
+	Report      string `yaml:"report"`
+	Jobs        int    `yaml:"jobs"`
+}
+
+// RecipeConfig names an active recipe and its options. In YAML it is either
+// a plain recipe name or a mapping with name and options.
+type RecipeConfig struct {
+	Name    string            `yaml:"name"`
+	Options map[string]string `yaml:"options"`
+}
+
+func (r *RecipeConfig) UnmarshalYAML(node *yaml.Node) error {
+	if node.Kind == yaml.ScalarNode {
+		r.Name = node.Value
+		return nil
+	}
+
+	// node.Decode does not reject unknown fields the way Parse does, so they are checked here
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		if key := node.Content[i]; key.Value != "name" && key.Value != "options" {
+			return fmt.Errorf("line %d: field %s not found in recipe", key.Line, key.Value)
+		}
+	}
+	type plain RecipeConfig
+	return node.Decode((*plain)(r))
+}
+
+// Parse decodes a configuration. Documents that declare a type, such as
+// declarative OpenRewrite recipes sharing rewrite.yml, are ignored. Unknown fields in the
+// configuration are rejected, so that a misspelled setting does not go unnoticed.
+func Parse(r io.Reader) (*Config, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read config: %w", err)
+	}
+
+	// The documents are decoded twice: loosely to find their type, then strictly
+	headers := yaml.NewDecoder(bytes.NewReader(data))
+	documents := yaml.NewDecoder(bytes.NewReader(data))
+	documents.KnownFields(true)
+
+	config := &Config{}
+	for {
+		var header struct {
+			Type string `yaml:"type"`
+		}
+		err := headers.Decode(&header)
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to decode config: %w", err)
+		}
+
+		var document interface{} = config
+		if header.Type != "" {
+			document = &yaml.Node{}
+		}
+		if err := documents.Decode(document); err != nil {
+			return nil, fmt.Errorf("failed to decode config: %w", err)
+		}
+	}
+
+	for i, recipe := range config.Recipes {
+		if strings.TrimSpace(recipe.Name) == "" {
+			return nil, fmt.Errorf("recipe %d in config has no name", i+1)
+		}
+	}
+	return config, nil
+}
+
+// Load reads the configuration file at path
+func Load(path string) (*Config, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open config %s: %w", path, err)
+	}
+	defer file.Close()
+
+	config, err := Parse(file)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return config, nil
+}
+
+// Find returns the path of the first configuration file present in projectPath,
+// or an empty string if there is none
+func Find(projectPath string) string {
+	for _, name := range FileNames {
+		path := filepath.Join(projectPath, name)
+		if info, err := os.Stat(path); err == nil && !info.IsDir() {
+			return path
+		}
+	}
+	return ""
+}
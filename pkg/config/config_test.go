@@ -0,0 +1,97 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestParse(t *testing.T) {
+	content := `---
+type: specs.openrewrite.org/v1beta/recipe
+name: com.example.Custom
+recipeList:
+  - org.openrewrite.java.migrate.UpgradeToJava17
+---
+recipes:
+  - org.openrewrite.java.migrate.UpgradeToJava17
+  - name: org.openrewrite.java.migrate.UseJavaUtilBase64
+    options:
+      useMimeCoder: true
+exclude:
+  - "**/legacy/**"
+sourceRoots:
+  - src/main/java
+output:
+  dryRun: true
+  diffContext: 1
+  jobs: 2
+`
+
+	cfg, err := Parse(strings.NewReader(content))
+	if err != nil {
+		t.Fatalf("Parse failed: %v", err)
+	}
+
+	if len(cfg.Recipes) != 2 {
+		t.Fatalf("Expected 2 recipes, got %d", len(cfg.Recipes))
+	}
+	if cfg.Recipes[0].Name != "org.openrewrite.java.migrate.UpgradeToJava17" {
+		t.Errorf("Unexpected first recipe %q", cfg.Recipes[0].Name)
+	}
+	if cfg.Recipes[1].Options["useMimeCoder"] != "true" {
+		t.Errorf("Expected useMimeCoder option, got %v", cfg.Recipes[1].Options)
+	}
+	if len(cfg.Exclude) != 1 || cfg.Exclude[0] != "**/legacy/**" {
+		t.Errorf("Unexpected excludes %v", cfg.Exclude)
+	}
+	if cfg.Output.DryRun == nil || !*cfg.Output.DryRun {
+		t.Error("Expected dryRun to be set")
+	}
+	if cfg.Output.DiffContext == nil || *cfg.Output.DiffContext != 1 || cfg.Output.Jobs != 2 {
+		t.Errorf("Unexpected output settings %+v", cfg.Output)
+	}
+}
+
+func TestParseRejectsUnnamedRecipe(t *testing.T) {
+	_, err := Parse(strings.NewReader("recipes:\n  - options:\n      version: 17\n"))
+	if err == nil {
+		t.Fatal("Expected an error for a recipe without a name")
+	}
+}
+
+func TestParseRejectsUnknownFields(t *testing.T) {
+	for _, content := range []string{
+		"version: 17\nsourceRoot:\n  - src\n",
+		"output:\n  dry-run: true\n",
+		"recipes:\n  - name: org.openrewrite.java.migrate.UpgradeJavaVersion\n    option:\n      version: 17\n",
+	} {
+		if _, err := Parse(strings.NewReader(content)); err == nil || !strings.Contains(err.Error(), "not found") {
+			t.Errorf("Expected an unknown field error for %q, got %v", content, err)
+		}
+	}
+}
+
+func TestFind(t *testing.T) {
+	dir := t.TempDir()
+	if path := Find(dir); path != "" {
+		t.Errorf("Expected no config, got %s", path)
+	}
+
+	hidden := filepath.Join(dir, ".rewrite.yml")
+	if err := os.WriteFile(hidden, []byte("version: 21\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if path := Find(dir); path != hidden {
+		t.Errorf("Expected %s, got %s", hidden, path)
+	}
+
+	cfg, err := Load(hidden)
+	if err != nil {
+		t.Fatalf("Load failed: %v", err)
+	}
+	if cfg.Version != 21 {
+		t.Errorf("Expected version 21, got %d", cfg.Version)
+	}
+}
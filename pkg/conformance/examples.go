@@ -0,0 +1,70 @@
+package conformance
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+
+	"gopkg.in/yaml.v3"
+)
+
+// ExampleType is the document type used by upstream examples.yml files
+const ExampleType = "specs.openrewrite.org/v1beta/example"
+
+// Spec holds the before/after examples declared for a single upstream recipe
+type Spec struct {
+	Type       string    `yaml:"type"`
+	RecipeName string    `yaml:"recipeName"`
+	Examples   []Example `yaml:"examples"`
+}
+
+// Example is one before/after scenario, optionally with positional recipe parameters
+type Example struct {
+	Description string   `yaml:"description"`
+	Parameters  []string `yaml:"parameters"`
+	Sources     []Source `yaml:"sources"`
+}
+
+// Source is a single file of an example. A nil After means the file must not change.
+type Source struct {
+	Before   string  `yaml:"before"`
+	After    *string `yaml:"after"`
+	Path     string  `yaml:"path"`
+	Language string  `yaml:"language"`
+}
+
+// LoadExamples decodes every example document from a multi-document YAML stream
+func LoadExamples(r io.Reader) ([]Spec, error) {
+	decoder := yaml.NewDecoder(r)
+
+	var specs []Spec
+	for {
+		var spec Spec
+		err := decoder.Decode(&spec)
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to decode examples: %w", err)
+		}
+
+		if spec.Type != ExampleType {
+			continue
+		}
+		specs = append(specs, spec)
+	}
+
+	return specs, nil
+}
+
+// LoadExamplesFile decodes the examples stored in the file at path
+func LoadExamplesFile(path string) ([]Spec, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open examples %s: %w", path, err)
+	}
+	defer file.Close()
+
+	return LoadExamples(file)
+}
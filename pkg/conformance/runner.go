@@ -0,0 +1,195 @@
+package conformance
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"sort"
+	"text/tabwriter"
+
+	"rewrite-migrate-java/pkg/java"
+	"rewrite-migrate-java/pkg/recipe"
+)
+
+// Status is the outcome of running a single example
+type Status string
+
+const (
+	StatusPass        Status = "pass"
+	StatusFail        Status = "fail"
+	StatusUnsupported Status = "unsupported"
+)
+
+// Result records the outcome of one example of one recipe
+type Result struct {
+	RecipeName  string
+	Example     int
+	Description string
+	Status      Status
+	Reason      string
+}
+
+// Scoreboard collects example results for a parity report
+type Scoreboard struct {
+	Results []Result
+}
+
+// Count returns the number of results with the given status
+func (s *Scoreboard) Count(status Status) int {
+	count := 0
+	for _, result := range s.Results {
+		if result.Status == status {
+			count++
+		}
+	}
+	return count
+}
+
+// Print writes a per-recipe summary followed by the overall totals
+func (s *Scoreboard) Print(w io.Writer) error {
+	type tally struct{ pass, fail, unsupported int }
+
+	byRecipe := make(map[string]*tally)
+	for _, result := range s.Results {
+		t, ok := byRecipe[result.RecipeName]
+		if !ok {
+			t = &tally{}
+			byRecipe[result.RecipeName] = t
+		}
+		switch result.Status {
+		case StatusPass:
+			t.pass++
+		case StatusFail:
+			t.fail++
+		case StatusUnsupported:
+			t.unsupported++
+		}
+	}
+
+	names := make([]string, 0, len(byRecipe))
+	for name := range byRecipe {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	tw := tabwriter.NewWriter(w, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(tw, "RECIPE\tPASS\tFAIL\tUNSUPPORTED")
+	for _, name := range names {
+		t := byRecipe[name]
+		fmt.Fprintf(tw, "%s\t%d\t%d\t%d\n", name, t.pass, t.fail, t.unsupported)
+	}
+	fmt.Fprintf(tw, "TOTAL\t%d\t%d\t%d\n",
+		s.Count(StatusPass), s.Count(StatusFail), s.Count(StatusUnsupported))
+	return tw.Flush()
+}
+
+// Runner applies registered Go recipes to upstream examples
+type Runner struct {
+	Registry *recipe.Registry
+}
+
+// Run executes every example of every spec and collects the results
+func (r *Runner) Run(ctx context.Context, specs []Spec) *Scoreboard {
+	scoreboard := &Scoreboard{}
+	for _, spec := range specs {
+		for i, example := range spec.Examples {
+			result := Result{
+				RecipeName:  spec.RecipeName,
+				Example:     i,
+				Description: example.Description,
+			}
+			result.Status, result.Reason = r.runExample(ctx, spec.RecipeName, example)
+			scoreboard.Results = append(scoreboard.Results, result)
+		}
+	}
+	return scoreboard
+}
+
+func (r *Runner) runExample(ctx context.Context, recipeName string, example Example) (Status, string) {
+	descriptor, ok := r.Registry.Lookup(recipeName)
+	if !ok {
+		return StatusUnsupported, "recipe is not registered"
+	}
+
+	options, err := descriptor.OptionsFromArgs(example.Parameters)
+	if err != nil {
+		return StatusUnsupported, err.Error()
+	}
+
+	migrationRecipe, err := r.Registry.New(recipeName, options)
+	if err != nil {
+		return StatusUnsupported, err.Error()
+	}
+
+	if migrationRecipe.GetVisitor() == nil {
+		return StatusUnsupported, "recipe has no visitor"
+	}
+
+	execCtx := recipe.NewExecutionContext(ctx)
+
+	for _, source := range example.Sources {
+		if isProjectMarker(source.Language) {
+			continue
+		}
+
+		sourceFile, err := newSourceFile(source)
+		if err != nil {
+			return StatusUnsupported, err.Error()
+		}
+
+		// Apply honours the applicability tests of the recipe and its children, like a run does
+		transformed, _, err := recipe.Apply(migrationRecipe, sourceFile, execCtx)
+		if err != nil {
+			return StatusFail, fmt.Sprintf("%s: %v", sourceFile.GetPath(), err)
+		}
+
+		expected := source.Before
+		if source.After != nil {
+			expected = *source.After
+		}
+		if transformed.GetContent() != expected {
+			return StatusFail, fmt.Sprintf("%s: output does not match the expected source", sourceFile.GetPath())
+		}
+	}
+
+	return StatusPass, ""
+}
+
+// isProjectMarker reports whether a source only marks the enclosing build project
+func isProjectMarker(language string) bool {
+	return language == "mavenProject" || language == "gradleProject"
+}
+
+// defaultPaths names sources of languages whose examples usually omit a path
+var defaultPaths = map[string]string{
+	"xml":        "file.xml",
+	"groovy":     "build.gradle",
+	"kotlin":     "build.gradle.kts",
+	"properties": "application.properties",
+	"yaml":       "file.yml",
+	"text":       "file.txt",
+}
+
+func newSourceFile(source Source) (recipe.SourceFile, error) {
+	if source.Language == "java" {
+		path := source.Path
+		if path == "" {
+			path = "Test.java"
+			if probe, err := java.NewJavaSourceFile(path, source.Before); err == nil && len(probe.GetClasses()) > 0 {
+				path = probe.GetClasses()[0].GetSimpleName() + ".java"
+			}
+		}
+		return java.NewJavaSourceFile(path, source.Before)
+	}
+
+	defaultPath, ok := defaultPaths[source.Language]
+	if !ok {
+		return nil, fmt.Errorf("unsupported source language %q", source.Language)
+	}
+
+	path := source.Path
+	if path == "" {
+		path = defaultPath
+	}
+	return recipe.NewSimpleSourceFile(path, source.Before), nil
+}
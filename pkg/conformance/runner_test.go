@@ -0,0 +1,160 @@
+package conformance
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"rewrite-migrate-java/pkg/migrate"
+	"rewrite-migrate-java/pkg/recipe"
+)
+
+const sampleExamples = `---
+type: specs.openrewrite.org/v1beta/example
+recipeName: org.openrewrite.java.migrate.UpgradeJavaVersion
+examples:
+- description: ''
+  parameters:
+  - '17'
+  sources:
+  - before: |
+      <project>
+        <properties>
+          <maven.compiler.source>8</maven.compiler.source>
+        </properties>
+      </project>
+    after: |
+      <project>
+        <properties>
+          <maven.compiler.source>17</maven.compiler.source>
+        </properties>
+      </project>
+    path: pom.xml
+    language: xml
+- description: ''
+  parameters:
+  - '17'
+  sources:
+  - before: |
+      <project>
+        <properties>
+          <maven.compiler.source>8</maven.compiler.source>
+        </properties>
+      </project>
+    path: pom.xml
+    language: xml
+---
+type: specs.openrewrite.org/v1beta/example
+recipeName: org.openrewrite.java.migrate.DoesNotExist
+examples:
+- description: ''
+  sources:
+  - before: class A {}
+    language: java
+`
+
+func TestRunnerScoresExamples(t *testing.T) {
+	specs, err := LoadExamples(strings.NewReader(sampleExamples))
+	if err != nil {
+		t.Fatalf("LoadExamples failed: %v", err)
+	}
+	if len(specs) != 2 {
+		t.Fatalf("Expected 2 specs, got %d", len(specs))
+	}
+
+	runner := &Runner{Registry: migrate.NewRegistry()}
+	scoreboard := runner.Run(context.Background(), specs)
+
+	expected := []Status{StatusPass, StatusFail, StatusUnsupported}
+	if len(scoreboard.Results) != len(expected) {
+		t.Fatalf("Expected %d results, got %d", len(expected), len(scoreboard.Results))
+	}
+	for i, status := range expected {
+		if scoreboard.Results[i].Status != status {
+			t.Errorf("Result %d: expected %s, got %s (%s)", i, status,
+				scoreboard.Results[i].Status, scoreboard.Results[i].Reason)
+		}
+	}
+}
+
+// TestUpstreamExamplesParity reports how many upstream examples the Go recipes reproduce
+func TestUpstreamExamplesParity(t *testing.T) {
+	specs, err := LoadExamplesFile("../../src/main/resources/META-INF/rewrite/examples.yml")
+	if err != nil {
+		t.Fatalf("LoadExamplesFile failed: %v", err)
+	}
+
+	runner := &Runner{Registry: migrate.NewRegistry()}
+	scoreboard := runner.Run(context.Background(), specs)
+
+	var out strings.Builder
+	if err := scoreboard.Print(&out); err != nil {
+		t.Fatalf("Print failed: %v", err)
+	}
+	t.Logf("Parity scoreboard:\n%s", out.String())
+
+	for _, result := range scoreboard.Results {
+		if result.Status == StatusFail {
+			t.Logf("%s example %d: %s", result.RecipeName, result.Example, result.Reason)
+		}
+	}
+}
+
+// rejectAll is a precondition no file passes
+type rejectAll struct{}
+
+func (rejectAll) Check(recipe.SourceFile) bool {
+	return false
+}
+
+// commentVisitor appends a comment to every file
+type commentVisitor struct{}
+
+func (commentVisitor) Visit(node recipe.SourceFile, ctx *recipe.ExecutionContext) (recipe.SourceFile, error) {
+	return node.WithContent(node.GetContent() + "// guarded\n"), nil
+}
+
+// guardedRecipe would append a comment to every file, were it not for its precondition
+type guardedRecipe struct {
+	*recipe.BaseRecipe
+}
+
+func (g *guardedRecipe) GetVisitor() recipe.TreeVisitor {
+	return commentVisitor{}
+}
+
+func (g *guardedRecipe) ApplicabilityTest() recipe.Precondition {
+	return rejectAll{}
+}
+
+func TestRunnerHonoursApplicabilityTests(t *testing.T) {
+	registry := recipe.NewRegistry()
+	err := registry.Register(recipe.Descriptor{
+		Name: "example.Guarded",
+		New: func(map[string]string) (recipe.Recipe, error) {
+			return &guardedRecipe{BaseRecipe: &recipe.BaseRecipe{Name: "example.Guarded"}}, nil
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	specs, err := LoadExamples(strings.NewReader(`---
+type: specs.openrewrite.org/v1beta/example
+recipeName: example.Guarded
+examples:
+- description: ''
+  sources:
+  - before: |
+      class A {}
+    language: java
+`))
+	if err != nil {
+		t.Fatalf("LoadExamples failed: %v", err)
+	}
+
+	scoreboard := (&Runner{Registry: registry}).Run(context.Background(), specs)
+	if len(scoreboard.Results) != 1 || scoreboard.Results[0].Status != StatusPass {
+		t.Errorf("Expected the rejected example to pass unchanged, got %+v", scoreboard.Results)
+	}
+}
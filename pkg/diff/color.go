@@ -0,0 +1,92 @@
+package diff
+
+import (
+	"os"
+	"strconv"
+	"strings"
+)
+
+const (
+	ansiReset = "\x1b[0m"
+	ansiBold  = "\x1b[1m"
+	ansiRed   = "\x1b[31m"
+	ansiGreen = "\x1b[32m"
+	ansiCyan  = "\x1b[36m"
+)
+
+// Colorize wraps the lines of a unified diff in ANSI color escapes. Lines starting with
+// --- or +++ are file headers only outside hunks, so a removed "-- " line stays a change.
+func Colorize(unified string) string {
+	var sb strings.Builder
+	// oldLines and newLines count the lines of the current hunk still to come
+	oldLines, newLines := 0, 0
+	for _, line := range SplitLines(unified) {
+		text := strings.TrimSuffix(line, "\n")
+		inHunk := oldLines > 0 || newLines > 0
+
+		var color string
+		switch {
+		case !inHunk && (strings.HasPrefix(text, "diff --git ") || strings.HasPrefix(text, "--- ") || strings.HasPrefix(text, "+++ ")):
+			color = ansiBold
+		case !inHunk && strings.HasPrefix(text, "@@"):
+			color = ansiCyan
+			oldLines, newLines = hunkLengths(text)
+		case strings.HasPrefix(text, "-"):
+			color = ansiRed
+			oldLines--
+		case strings.HasPrefix(text, "+"):
+			color = ansiGreen
+			newLines--
+		case strings.HasPrefix(text, " "):
+			oldLines--
+			newLines--
+		}
+
+		if color == "" {
+			sb.WriteString(line)
+			continue
+		}
+		sb.WriteString(color)
+		sb.WriteString(text)
+		sb.WriteString(ansiReset)
+		if strings.HasSuffix(line, "\n") {
+			sb.WriteByte('\n')
+		}
+	}
+	return sb.String()
+}
+
+// hunkLengths returns the number of old and new lines a hunk header such as
+// "@@ -1,3 +1,4 @@" announces. A range without a length is a single line.
+func hunkLengths(header string) (int, int) {
+	fields := strings.Fields(header)
+	if len(fields) < 3 {
+		return 0, 0
+	}
+	return rangeLength(fields[1]), rangeLength(fields[2])
+}
+
+func rangeLength(r string) int {
+	_, length, found := strings.Cut(r, ",")
+	if !found {
+		return 1
+	}
+	n, err := strconv.Atoi(length)
+	if err != nil {
+		return 0
+	}
+	return n
+}
+
+// ColorEnabled reports whether diffs written to f should be colorized.
+// Color is disabled when NO_COLOR is set or f is not a terminal.
+func ColorEnabled(f *os.File) bool {
+	if os.Getenv("NO_COLOR") != "" {
+		return false
+	}
+	info, err := f.Stat()
+	if err != nil {
+		return false
+	}
+	return info.Mode()&os.ModeCharDevice != 0
+}
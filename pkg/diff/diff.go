@@ -0,0 +1,381 @@
+package diff
+
+import (
+	"fmt"
+	"strings"
+)
+
+// DefaultContext is the number of unchanged lines shown around each change
+const DefaultContext = 3
+
+// Op identifies how a line takes part in a diff
+type Op byte
+
+const (
+	Equal  Op = ' '
+	Delete Op = '-'
+	Insert Op = '+'
+)
+
+// Line is a single line of a hunk, including its line terminator if it has one
+type Line struct {
+	Op   Op
+	Text string
+}
+
+// Hunk is a contiguous group of changes with surrounding context
+type Hunk struct {
+	OldStart int
+	OldLines int
+	NewStart int
+	NewLines int
+	Lines    []Line
+}
+
+// Header returns the @@ range line of the hunk
+func (h Hunk) Header() string {
+	return fmt.Sprintf("@@ -%s +%s @@", formatRange(h.OldStart, h.OldLines), formatRange(h.NewStart, h.NewLines))
+}
+
+// String renders the hunk with its header as it appears in a unified diff
+func (h Hunk) String() string {
+	var sb strings.Builder
+	writeHunks(&sb, []Hunk{h})
+	return sb.String()
+}
+
+func formatRange(start, lines int) string {
+	if lines == 1 {
+		return fmt.Sprintf("%d", start)
+	}
+	return fmt.Sprintf("%d,%d", start, lines)
+}
+
+// SplitLines splits content into lines, keeping each line's terminator
+func SplitLines(content string) []string {
+	if content == "" {
+		return nil
+	}
+	lines := strings.SplitAfter(content, "\n")
+	if lines[len(lines)-1] == "" {
+		lines = lines[:len(lines)-1]
+	}
+	return lines
+}
+
+// Hunks computes the hunks needed to turn before into after
+func Hunks(before, after string, context int) []Hunk {
+	if context < 0 {
+		context = 0
+	}
+
+	a := SplitLines(before)
+	b := SplitLines(after)
+	edits := compute(a, b)
+
+	var hunks []Hunk
+	for i := 0; i < len(edits); {
+		if edits[i].Op == Equal {
+			i++
+			continue
+		}
+
+		// Walk back to include leading context
+		start := i
+		for start > 0 && i-start < context && edits[start-1].Op == Equal {
+			start--
+		}
+
+		// Extend forward while the gap between changes is small enough to merge
+		end := i
+		for end < len(edits) {
+			if edits[end].Op != Equal {
+				end++
+				continue
+			}
+			run := end
+			for run < len(edits) && edits[run].Op == Equal {
+				run++
+			}
+			if run < len(edits) && run-end <= 2*context {
+				end = run
+				continue
+			}
+			end += min(context, run-end)
+			break
+		}
+
+		hunk := Hunk{
+			OldStart: edits[start].oldIndex + 1,
+			NewStart: edits[start].newIndex + 1,
+		}
+		for _, e := range edits[start:end] {
+			hunk.Lines = append(hunk.Lines, e.Line)
+			if e.Op != Insert {
+				hunk.OldLines++
+			}
+			if e.Op != Delete {
+				hunk.NewLines++
+			}
+		}
+		// Empty ranges are reported against the line before them
+		if hunk.OldLines == 0 {
+			hunk.OldStart--
+		}
+		if hunk.NewLines == 0 {
+			hunk.NewStart--
+		}
+
+		hunks = append(hunks, hunk)
+		i = end
+	}
+
+	return hunks
+}
+
+// Unified renders a unified diff between before and after with the given file labels
+func Unified(oldLabel, newLabel, before, after string, context int) string {
+	hunks := Hunks(before, after, context)
+	if len(hunks) == 0 {
+		return ""
+	}
+
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "--- %s\n", oldLabel)
+	fmt.Fprintf(&sb, "+++ %s\n", newLabel)
+	writeHunks(&sb, hunks)
+	return sb.String()
+}
+
+func writeHunks(sb *strings.Builder, hunks []Hunk) {
+	for _, hunk := range hunks {
+		sb.WriteString(hunk.Header())
+		sb.WriteByte('\n')
+		for _, line := range hunk.Lines {
+			sb.WriteByte(byte(line.Op))
+			sb.WriteString(line.Text)
+			if !strings.HasSuffix(line.Text, "\n") {
+				sb.WriteString("\n\\ No newline at end of file\n")
+			}
+		}
+	}
+}
+
+// edit is a Line annotated with its position in the old and new inputs
+type edit struct {
+	Line
+	oldIndex int
+	newIndex int
+}
+
+// compute returns the shortest edit script between a and b using the linear space variant of
+// Myers' algorithm: the middle snake of a shortest path splits the inputs in two halves that
+// are diffed on their own, so only two vectors of diagonals are kept at any time
+func compute(a, b []string) []edit {
+	size := len(a) + len(b) + 2
+	s := &script{
+		a:        a,
+		b:        b,
+		offset:   size,
+		forward:  make([]int, 2*size+1),
+		backward: make([]int, 2*size+1),
+	}
+	s.diff(0, len(a), 0, len(b))
+	return deletionsFirst(s.edits)
+}
+
+// deletionsFirst moves the deletions of every run of changes before its insertions, the
+// order unified diffs show them in
+func deletionsFirst(edits []edit) []edit {
+	result := make([]edit, 0, len(edits))
+	var inserts []edit
+	x, y := 0, 0
+	flush := func() {
+		for _, e := range inserts {
+			result = append(result, edit{e.Line, x, y})
+			y++
+		}
+		inserts = inserts[:0]
+	}
+	for _, e := range edits {
+		switch e.Op {
+		case Insert:
+			inserts = append(inserts, e)
+		case Delete:
+			result = append(result, edit{e.Line, x, y})
+			x++
+		default:
+			flush()
+			result = append(result, edit{e.Line, x, y})
+			x++
+			y++
+		}
+	}
+	flush()
+	return result
+}
+
+// script accumulates the edits of a diff in order
+type script struct {
+	a, b  []string
+	edits []edit
+	// x and y are the number of lines of a and b the edits so far consumed
+	x, y int
+
+	// offset maps diagonal k to index offset+k of the vectors of furthest reaching paths
+	offset            int
+	forward, backward []int
+}
+
+func (s *script) equal() {
+	s.edits = append(s.edits, edit{Line{Equal, s.a[s.x]}, s.x, s.y})
+	s.x++
+	s.y++
+}
+
+func (s *script) delete() {
+	s.edits = append(s.edits, edit{Line{Delete, s.a[s.x]}, s.x, s.y})
+	s.x++
+}
+
+func (s *script) insert() {
+	s.edits = append(s.edits, edit{Line{Insert, s.b[s.y]}, s.x, s.y})
+	s.y++
+}
+
+// diff appends the edits turning a[aLo:aHi] into b[bLo:bHi]
+func (s *script) diff(aLo, aHi, bLo, bHi int) {
+	for aLo < aHi && bLo < bHi && s.a[aLo] == s.b[bLo] {
+		s.equal()
+		aLo++
+		bLo++
+	}
+	suffix := 0
+	for aLo < aHi && bLo < bHi && s.a[aHi-1] == s.b[bHi-1] {
+		aHi--
+		bHi--
+		suffix++
+	}
+
+	switch {
+	case aLo == aHi:
+		for ; bLo < bHi; bLo++ {
+			s.insert()
+		}
+	case bLo == bHi:
+		for ; aLo < aHi; aLo++ {
+			s.delete()
+		}
+	default:
+		x, y, u, v := s.middleSnake(aLo, aHi, bLo, bHi)
+		s.diff(aLo, x, bLo, y)
+		for ; x < u; x++ {
+			s.equal()
+		}
+		s.diff(u, aHi, v, bHi)
+	}
+
+	for ; suffix > 0; suffix-- {
+		s.equal()
+	}
+}
+
+// middleSnake searches for a shortest path through a[aLo:aHi] and b[bLo:bHi] from both ends
+// at once and returns the snake where the searches meet, from (x, y) to (u, v)
+func (s *script) middleSnake(aLo, aHi, bLo, bHi int) (x, y, u, v int) {
+	n, m := aHi-aLo, bHi-bLo
+	delta := n - m
+	odd := delta%2 != 0
+	off, forward, backward := s.offset, s.forward, s.backward
+	forward[off+1], backward[off+1] = 0, 0
+
+	for d := 0; d <= (n+m+1)/2; d++ {
+		// Forward paths on diagonal k = x - y
+		for k := -d; k <= d; k += 2 {
+			var x int
+			if k == -d || (k != d && forward[off+k-1] < forward[off+k+1]) {
+				x = forward[off+k+1]
+			} else {
+				x = forward[off+k-1] + 1
+			}
+			y := x - k
+			startX, startY := x, y
+			for x < n && y < m && s.a[aLo+x] == s.b[bLo+y] {
+				x++
+				y++
+			}
+			forward[off+k] = x
+			// Backward paths count from the end, so forward diagonal k is backward diagonal delta-k
+			if kr := delta - k; odd && kr >= -(d-1) && kr <= d-1 && x+backward[off+kr] >= n {
+				return aLo + startX, bLo + startY, aLo + x, bLo + y
+			}
+		}
+
+		// Backward paths on diagonal k = (n - x) - (m - y)
+		for k := -d; k <= d; k += 2 {
+			var x int
+			if k == -d || (k != d && backward[off+k-1] < backward[off+k+1]) {
+				x = backward[off+k+1]
+			} else {
+				x = backward[off+k-1] + 1
+			}
+			y := x - k
+			startX, startY := x, y
+			for x < n && y < m && s.a[aHi-1-x] == s.b[bHi-1-y] {
+				x++
+				y++
+			}
+			backward[off+k] = x
+			if kf := delta - k; !odd && kf >= -d && kf <= d && x+forward[off+kf] >= n {
+				return aHi - x, bHi - y, aHi - startX, bHi - startY
+			}
+		}
+	}
+	panic("diff: no middle snake found")
+}
+
+// OriginLines maps every line of after to the zero-based index of the line of
+// before it was kept from, or -1 for lines that were inserted
+func OriginLines(before, after string) []int {
+	edits := compute(SplitLines(before), SplitLines(after))
+
+	origins := make([]int, 0, len(edits))
+	for _, e := range edits {
+		switch e.Op {
+		case Equal:
+			origins = append(origins, e.oldIndex)
+		case Insert:
+			origins = append(origins, -1)
+		}
+	}
+	return origins
+}
+
+// ApplyHunks applies hunks computed against before, in order, and returns the result.
+// Hunks left out are not applied, so a subset of the hunks of a diff can be selected.
+func ApplyHunks(before string, hunks []Hunk) string {
+	lines := SplitLines(before)
+
+	var sb strings.Builder
+	next := 0
+	for _, hunk := range hunks {
+		// Hunks without old lines insert after OldStart; others replace from OldStart
+		start := hunk.OldStart
+		if hunk.OldLines > 0 {
+			start--
+		}
+		for ; next < start; next++ {
+			sb.WriteString(lines[next])
+		}
+		for _, line := range hunk.Lines {
+			if line.Op != Delete {
+				sb.WriteString(line.Text)
+			}
+		}
+		next += hunk.OldLines
+	}
+	for ; next < len(lines); next++ {
+		sb.WriteString(lines[next])
+	}
+	return sb.String()
+}
@@ -0,0 +1,246 @@
+package diff
+
+import (
+	"fmt"
+	"math/rand"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+)
+
+func TestUnified(t *testing.T) {
+	before := "a\nb\nc\nd\ne\nf\ng\nh\n"
+	after := "a\nb\nc\nD\ne\nf\ng\nh\ni\n"
+
+	expected := `--- Example.java
++++ Example.java
+@@ -1,8 +1,9 @@
+ a
+ b
+ c
+-d
++D
+ e
+ f
+ g
+ h
++i
+`
+
+	got := Unified("Example.java", "Example.java", before, after, DefaultContext)
+	if got != expected {
+		t.Errorf("Expected:\n%s\nGot:\n%s", expected, got)
+	}
+}
+
+func TestUnifiedSplitsDistantHunks(t *testing.T) {
+	before := "1\n2\n3\n4\n5\n6\n7\n8\n9\n10\n"
+	after := "one\n2\n3\n4\n5\n6\n7\n8\n9\nten\n"
+
+	hunks := Hunks(before, after, 1)
+	if len(hunks) != 2 {
+		t.Fatalf("Expected 2 hunks, got %d", len(hunks))
+	}
+	if hunks[0].Header() != "@@ -1,2 +1,2 @@" {
+		t.Errorf("Unexpected first hunk header %q", hunks[0].Header())
+	}
+	if hunks[1].Header() != "@@ -9,2 +9,2 @@" {
+		t.Errorf("Unexpected second hunk header %q", hunks[1].Header())
+	}
+}
+
+func TestUnifiedMissingTrailingNewline(t *testing.T) {
+	got := Unified("a", "b", "x\ny", "x\nz", 0)
+	expected := "--- a\n+++ b\n@@ -2 +2 @@\n-y\n\\ No newline at end of file\n+z\n\\ No newline at end of file\n"
+	if got != expected {
+		t.Errorf("Expected:\n%q\nGot:\n%q", expected, got)
+	}
+}
+
+func TestUnifiedNoChanges(t *testing.T) {
+	if got := Unified("a", "b", "same\n", "same\n", DefaultContext); got != "" {
+		t.Errorf("Expected empty diff, got %q", got)
+	}
+}
+
+func TestColorize(t *testing.T) {
+	got := Colorize("--- a\n+++ b\n@@ -1 +1 @@\n-x\n+y\n")
+	if !strings.Contains(got, ansiRed+"-x"+ansiReset) || !strings.Contains(got, ansiGreen+"+y"+ansiReset) {
+		t.Errorf("Expected colored change lines, got %q", got)
+	}
+}
+
+func TestColorizeRemovedHeaderLikeLines(t *testing.T) {
+	unified := Unified("a/Example.sql", "b/Example.sql", "-- comment\n++ counter\nselect 1;\n", "select 1;\n", DefaultContext)
+	unified += Unified("a/Other.sql", "b/Other.sql", "x\n", "y\n", DefaultContext)
+
+	got := Colorize(unified)
+	for _, expected := range []string{
+		ansiBold + "--- a/Example.sql" + ansiReset,
+		ansiRed + "--- comment" + ansiReset,
+		ansiRed + "-++ counter" + ansiReset,
+		ansiBold + "--- a/Other.sql" + ansiReset,
+		ansiBold + "+++ b/Other.sql" + ansiReset,
+		ansiRed + "-x" + ansiReset,
+	} {
+		if !strings.Contains(got, expected) {
+			t.Errorf("Expected %q in %q", expected, got)
+		}
+	}
+}
+
+func TestGitPatch(t *testing.T) {
+	changes := []FileChange{
+		{Path: "pom.xml", Before: "<source>8</source>\n", After: "<source>17</source>\n"},
+		{Path: "src/Same.java", Before: "class Same {}\n", After: "class Same {}\n"},
+	}
+
+	expected := `diff --git a/pom.xml b/pom.xml
+--- a/pom.xml
++++ b/pom.xml
+@@ -1 +1 @@
+-<source>8</source>
++<source>17</source>
+`
+
+	if got := GitPatch(changes, DefaultContext); got != expected {
+		t.Errorf("Expected:\n%s\nGot:\n%s", expected, got)
+	}
+}
+
+func TestGitPatchApplies(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git is not installed")
+	}
+
+	var long []string
+	for i := 0; i < 20; i++ {
+		long = append(long, fmt.Sprintf("line %d", i))
+	}
+	edited := append([]string{}, long...)
+	edited[1], edited[18] = "first edit", "second edit"
+	changes := []FileChange{
+		{Path: "pom.xml", Before: "<source>8</source>\n", After: "<source>17</source>\n"},
+		{Path: "src/Long.java", Before: strings.Join(long, "\n") + "\n", After: strings.Join(edited, "\n") + "\n"},
+		{Path: "src/NoNewline.java", Before: "class A {}", After: "class B {}"},
+	}
+
+	dir := t.TempDir()
+	for _, change := range changes {
+		name := filepath.Join(dir, filepath.FromSlash(change.Path))
+		if err := os.MkdirAll(filepath.Dir(name), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(name, []byte(change.Before), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	patch := filepath.Join(t.TempDir(), "changes.patch")
+	if err := os.WriteFile(patch, []byte(GitPatch(changes, DefaultContext)), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	cmd := exec.Command("git", "apply", "--check", patch)
+	cmd.Dir = dir
+	if output, err := cmd.CombinedOutput(); err != nil {
+		t.Errorf("git apply --check rejected the patch: %v\n%s", err, output)
+	}
+}
+
+func TestOriginLines(t *testing.T) {
+	got := OriginLines("a\nb\nc\n", "a\nx\nc\nd\n")
+	expected := []int{0, -1, 2, -1}
+	if len(got) != len(expected) {
+		t.Fatalf("Expected %v, got %v", expected, got)
+	}
+	for i := range expected {
+		if got[i] != expected[i] {
+			t.Fatalf("Expected %v, got %v", expected, got)
+		}
+	}
+}
+
+func TestApplyHunks(t *testing.T) {
+	before := "1\n2\n3\n4\n5\n6\n7\n8\n9\n10\n"
+	after := "0\none\n2\n3\n4\n5\n6\n7\n8\n9\n"
+
+	hunks := Hunks(before, after, 1)
+	if len(hunks) != 2 {
+		t.Fatalf("Expected 2 hunks, got %d", len(hunks))
+	}
+
+	if got := ApplyHunks(before, hunks); got != after {
+		t.Errorf("Expected all hunks to produce %q, got %q", after, got)
+	}
+	if got := ApplyHunks(before, hunks[:1]); got != "0\none\n2\n3\n4\n5\n6\n7\n8\n9\n10\n" {
+		t.Errorf("Unexpected result of first hunk %q", got)
+	}
+	if got := ApplyHunks(before, hunks[1:]); got != "1\n2\n3\n4\n5\n6\n7\n8\n9\n" {
+		t.Errorf("Unexpected result of second hunk %q", got)
+	}
+	if got := ApplyHunks(before, nil); got != before {
+		t.Errorf("Expected no hunks to keep the content, got %q", got)
+	}
+
+	// Pure insertions at the start and end of the content
+	inserted := "first\n" + before + "last\n"
+	if got := ApplyHunks(before, Hunks(before, inserted, 0)); got != inserted {
+		t.Errorf("Expected %q, got %q", inserted, got)
+	}
+}
+
+// numberedLines returns n lines, each holding prefix and its number
+func numberedLines(prefix string, n int) string {
+	var sb strings.Builder
+	for i := 0; i < n; i++ {
+		fmt.Fprintf(&sb, "%s %d\n", prefix, i)
+	}
+	return sb.String()
+}
+
+func TestHunksRoundTrip(t *testing.T) {
+	r := rand.New(rand.NewSource(1))
+	randomLines := func() string {
+		var sb strings.Builder
+		for i := r.Intn(20); i > 0; i-- {
+			fmt.Fprintf(&sb, "%c\n", 'a'+r.Intn(4))
+		}
+		return sb.String()
+	}
+
+	for i := 0; i < 1000; i++ {
+		before, after := randomLines(), randomLines()
+		if got := ApplyHunks(before, Hunks(before, after, 0)); got != after {
+			t.Fatalf("Applying the hunks of %q to %q gave %q", before, after, got)
+		}
+	}
+}
+
+func TestHunksOfLargeRewriteUseLinearMemory(t *testing.T) {
+	// Every line changes, the longest edit script there is
+	before, after := numberedLines("old", 8000), numberedLines("new", 8000)
+
+	var start, end runtime.MemStats
+	runtime.ReadMemStats(&start)
+	hunks := Hunks(before, after, DefaultContext)
+	runtime.ReadMemStats(&end)
+
+	if len(hunks) != 1 || hunks[0].OldLines != 8000 || hunks[0].NewLines != 8000 {
+		t.Fatalf("Expected one hunk replacing every line, got %d hunks", len(hunks))
+	}
+	if allocated := end.TotalAlloc - start.TotalAlloc; allocated > 32<<20 {
+		t.Errorf("Expected the diff to allocate less than 32MB, got %dMB", allocated>>20)
+	}
+}
+
+func BenchmarkHunks(b *testing.B) {
+	before := numberedLines("line", 8000)
+	after := strings.ReplaceAll(before, "line 1", "changed 1")
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		Hunks(before, after, DefaultContext)
+	}
+}
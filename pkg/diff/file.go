@@ -0,0 +1,13 @@
+package diff
+
+// FileChange describes the content of a single file before and after a run
+type FileChange struct {
+	Path   string
+	Before string
+	After  string
+}
+
+// Unified renders the change as a unified diff labeled with the file path
+func (c FileChange) Unified(context int) string {
+	return Unified(c.Path, c.Path, c.Before, c.After, context)
+}
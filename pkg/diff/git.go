@@ -0,0 +1,24 @@
+package diff
+
+import (
+	"fmt"
+	"strings"
+)
+
+// GitPatch renders changes as a single patch in git diff format that git apply accepts.
+// Recipes only edit existing files and keep their mode, so the patch has no mode headers.
+func GitPatch(changes []FileChange, context int) string {
+	var sb strings.Builder
+	for _, change := range changes {
+		hunks := Hunks(change.Before, change.After, context)
+		if len(hunks) == 0 {
+			continue
+		}
+
+		fmt.Fprintf(&sb, "diff --git a/%s b/%s\n", change.Path, change.Path)
+		fmt.Fprintf(&sb, "--- a/%s\n", change.Path)
+		fmt.Fprintf(&sb, "+++ b/%s\n", change.Path)
+		writeHunks(&sb, hunks)
+	}
+	return sb.String()
+}
@@ -32,6 +32,14 @@ func NewJavaSourceFile(path, content string) (*JavaSourceFile, error) {
 	return jsf, nil
 }
 
+// ParseSource parses Java files and wraps any other file, such as a build file, as plain text
+func ParseSource(path, content string) (recipe.SourceFile, error) {
+	if !strings.HasSuffix(path, ".java") {
+		return recipe.NewSimpleSourceFile(path, content), nil
+	}
+	return NewJavaSourceFile(path, content)
+}
+
 func (jsf *JavaSourceFile) GetPath() string {
 	return jsf.path
 }
@@ -0,0 +1,221 @@
+package journal
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"rewrite-migrate-java/pkg/project"
+	"rewrite-migrate-java/pkg/textfile"
+)
+
+// DirName is the journal directory, relative to the project root
+const DirName = ".rewrite-journal"
+
+const (
+	runFileName   = "journal.json"
+	backupDirName = "backups"
+)
+
+// Run is the journal of a single migration run
+type Run struct {
+	ID        string    `json:"id"`
+	Recipe    string    `json:"recipe"`
+	StartedAt time.Time `json:"startedAt"`
+	Files     []Entry   `json:"files"`
+}
+
+// Entry records a single file written by a run. The original content is kept
+// as a backup named after its hash.
+type Entry struct {
+	// Path is relative to the project root and uses forward slashes
+	Path   string      `json:"path"`
+	Before string      `json:"before"`
+	After  string      `json:"after"`
+	Mode   fs.FileMode `json:"mode"`
+}
+
+// Journal records the files written by a run so that they can be restored with Undo
+type Journal struct {
+	root string
+	dir  string
+	run  Run
+
+	mu sync.Mutex
+}
+
+// Begin starts the journal of a new run over root. Nothing is stored until the
+// first file is recorded.
+func Begin(root, recipeName string) *Journal {
+	now := time.Now().UTC()
+	id := now.Format("20060102T150405.000000000Z")
+	return &Journal{
+		root: root,
+		dir:  filepath.Join(root, DirName, id),
+		run: Run{
+			ID:        id,
+			Recipe:    recipeName,
+			StartedAt: now,
+			Files:     []Entry{},
+		},
+	}
+}
+
+// Write backs up the current content of path, records the change in the journal
+// and then atomically replaces the file with after.
+func (j *Journal) Write(path string, before, after []byte, mode fs.FileMode) error {
+	relPath, err := filepath.Rel(j.root, path)
+	if err != nil {
+		return fmt.Errorf("failed to journal %s: %w", path, err)
+	}
+
+	entry := Entry{
+		Path:   filepath.ToSlash(relPath),
+		Before: hash(before),
+		After:  hash(after),
+		Mode:   mode,
+	}
+
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	if err := project.IgnoreDir(filepath.Join(j.root, DirName)); err != nil {
+		return fmt.Errorf("failed to create journal: %w", err)
+	}
+	backup := filepath.Join(j.dir, backupDirName, entry.Before)
+	if err := os.MkdirAll(filepath.Dir(backup), 0755); err != nil {
+		return fmt.Errorf("failed to create journal: %w", err)
+	}
+	if err := textfile.WriteBytes(backup, before, 0644); err != nil {
+		return fmt.Errorf("failed to back up %s: %w", entry.Path, err)
+	}
+
+	// The journal is saved before the file is replaced, so an interrupted run can still be undone
+	j.run.Files = append(j.run.Files, entry)
+	if err := j.save(); err != nil {
+		return err
+	}
+	return textfile.WriteBytes(path, after, mode)
+}
+
+func (j *Journal) save() error {
+	data, err := json.MarshalIndent(j.run, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode journal: %w", err)
+	}
+	if err := textfile.WriteBytes(filepath.Join(j.dir, runFileName), append(data, '\n'), 0644); err != nil {
+		return fmt.Errorf("failed to save journal: %w", err)
+	}
+	return nil
+}
+
+// Last returns the directory and journal of the most recent run over root
+func Last(root string) (string, *Run, error) {
+	base := filepath.Join(root, DirName)
+	entries, err := os.ReadDir(base)
+	if err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return "", nil, fmt.Errorf("failed to read journal: %w", err)
+	}
+
+	var ids []string
+	for _, entry := range entries {
+		if entry.IsDir() {
+			ids = append(ids, entry.Name())
+		}
+	}
+	if len(ids) == 0 {
+		return "", nil, fmt.Errorf("no migration run recorded in %s", base)
+	}
+	// IDs are timestamps, so the last one in lexical order is the most recent
+	sort.Strings(ids)
+	dir := filepath.Join(base, ids[len(ids)-1])
+
+	data, err := os.ReadFile(filepath.Join(dir, runFileName))
+	if err != nil {
+		return "", nil, fmt.Errorf("failed to read journal: %w", err)
+	}
+	var run Run
+	if err := json.Unmarshal(data, &run); err != nil {
+		return "", nil, fmt.Errorf("failed to decode journal %s: %w", dir, err)
+	}
+	return dir, &run, nil
+}
+
+// ModifiedError lists the files edited after the run that Undo would restore
+type ModifiedError struct {
+	Paths []string
+}
+
+func (e *ModifiedError) Error() string {
+	return fmt.Sprintf("files were modified after the last run: %s", strings.Join(e.Paths, ", "))
+}
+
+// Undo restores every file written by the most recent run over root to its original
+// content and mode, then removes that run from the journal. Nothing is restored if any
+// file was edited since the run; a *ModifiedError lists those files.
+func Undo(root string) (*Run, error) {
+	dir, run, err := Last(root)
+	if err != nil {
+		return nil, err
+	}
+
+	// A file may be recorded several times by one run; the first entry holds its original content
+	originals := make(map[string]Entry)
+	var order []string
+	for _, entry := range run.Files {
+		if _, seen := originals[entry.Path]; !seen {
+			originals[entry.Path] = entry
+			order = append(order, entry.Path)
+		}
+	}
+	latest := make(map[string]string)
+	for _, entry := range run.Files {
+		latest[entry.Path] = entry.After
+	}
+
+	// Check every file before restoring any of them
+	modified := &ModifiedError{}
+	for _, path := range order {
+		content, err := os.ReadFile(filepath.Join(root, filepath.FromSlash(path)))
+		if err != nil {
+			return nil, fmt.Errorf("failed to read %s: %w", path, err)
+		}
+		// A file still holding its original content was not written before the run stopped
+		if current := hash(content); current != latest[path] && current != originals[path].Before {
+			modified.Paths = append(modified.Paths, path)
+		}
+	}
+	if len(modified.Paths) > 0 {
+		return nil, modified
+	}
+
+	for _, path := range order {
+		entry := originals[path]
+		backup, err := os.ReadFile(filepath.Join(dir, backupDirName, entry.Before))
+		if err != nil {
+			return nil, fmt.Errorf("failed to read backup of %s: %w", path, err)
+		}
+		if err := textfile.WriteBytes(filepath.Join(root, filepath.FromSlash(path)), backup, entry.Mode); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := os.RemoveAll(dir); err != nil {
+		return nil, fmt.Errorf("failed to remove journal %s: %w", dir, err)
+	}
+	return run, nil
+}
+
+func hash(data []byte) string {
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,113 @@
+package journal
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func writeFile(t *testing.T, path, content string, mode os.FileMode) {
+	t.Helper()
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, []byte(content), mode); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chmod(path, mode); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func readFile(t *testing.T, path string) string {
+	t.Helper()
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(data)
+}
+
+func TestUndoRestoresLastRun(t *testing.T) {
+	root := t.TempDir()
+	a := filepath.Join(root, "src/A.java")
+	b := filepath.Join(root, "gradlew")
+	writeFile(t, a, "class A {}\n", 0644)
+	writeFile(t, b, "#!/bin/sh\n", 0755)
+
+	first := Begin(root, "first")
+	if err := first.Write(a, []byte("class A {}\n"), []byte("class A { int x; }\n"), 0644); err != nil {
+		t.Fatalf("Write failed: %v", err)
+	}
+
+	second := Begin(root, "second")
+	// Make sure the second run sorts after the first even on coarse clocks
+	second.run.ID = first.run.ID + "-2"
+	second.dir = second.dir + "-2"
+	if err := second.Write(b, []byte("#!/bin/sh\n"), []byte("#!/bin/sh\nexec java\n"), 0755); err != nil {
+		t.Fatalf("Write failed: %v", err)
+	}
+	if err := second.Write(b, []byte("#!/bin/sh\nexec java\n"), []byte("#!/bin/sh\nexec java \"$@\"\n"), 0755); err != nil {
+		t.Fatalf("Write failed: %v", err)
+	}
+
+	run, err := Undo(root)
+	if err != nil {
+		t.Fatalf("Undo failed: %v", err)
+	}
+	if run.Recipe != "second" {
+		t.Errorf("Expected the second run to be undone, got %s", run.Recipe)
+	}
+	if content := readFile(t, b); content != "#!/bin/sh\n" {
+		t.Errorf("Expected gradlew to be restored, got %q", content)
+	}
+	if info, _ := os.Stat(b); info.Mode().Perm() != 0755 {
+		t.Errorf("Expected mode 0755, got %v", info.Mode().Perm())
+	}
+	if content := readFile(t, a); content != "class A { int x; }\n" {
+		t.Errorf("Expected A.java to keep the first run's change, got %q", content)
+	}
+
+	if _, err := Undo(root); err != nil {
+		t.Fatalf("Undo failed: %v", err)
+	}
+	if content := readFile(t, a); content != "class A {}\n" {
+		t.Errorf("Expected A.java to be restored, got %q", content)
+	}
+
+	if _, err := Undo(root); err == nil {
+		t.Error("Expected an error when no run is left")
+	}
+}
+
+func TestUndoRefusesModifiedFiles(t *testing.T) {
+	root := t.TempDir()
+	a := filepath.Join(root, "A.java")
+	b := filepath.Join(root, "B.java")
+	writeFile(t, a, "a\n", 0644)
+	writeFile(t, b, "b\n", 0644)
+
+	j := Begin(root, "recipe")
+	if err := j.Write(a, []byte("a\n"), []byte("A\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := j.Write(b, []byte("b\n"), []byte("B\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	writeFile(t, b, "edited\n", 0644)
+
+	_, err := Undo(root)
+	var modified *ModifiedError
+	if !errors.As(err, &modified) {
+		t.Fatalf("Expected a ModifiedError, got %v", err)
+	}
+	if !reflect.DeepEqual(modified.Paths, []string{"B.java"}) {
+		t.Errorf("Unexpected modified files %v", modified.Paths)
+	}
+	// Nothing is restored when any file was modified
+	if content := readFile(t, a); content != "A\n" {
+		t.Errorf("Expected A.java to be left alone, got %q", content)
+	}
+}
@@ -0,0 +1,318 @@
+package lsp
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/url"
+	"strings"
+
+	"rewrite-migrate-java/pkg/diff"
+	"rewrite-migrate-java/pkg/java"
+	"rewrite-migrate-java/pkg/recipe"
+)
+
+// ServerName identifies the server to clients and is the source of its diagnostics
+const ServerName = "rewrite-migrate-java"
+
+// textDocumentSyncFull asks clients to send the whole document on every change
+const textDocumentSyncFull = 1
+
+// Server is a language server that reports what migration recipes would change in open
+// documents as diagnostics, and offers every applicable recipe as a code action
+type Server struct {
+	recipes []recipe.Recipe
+	leaves  []recipe.Recipe
+
+	conn      *Conn
+	ctx       *recipe.ExecutionContext
+	documents map[string]*document
+	shutdown  bool
+}
+
+// document is an open text document and the recipes that would change it
+type document struct {
+	path        string
+	text        string
+	diagnostics []Diagnostic
+	fixes       []fix
+}
+
+// fix is the edit a recipe makes to a document
+type fix struct {
+	recipe recipe.Recipe
+	edits  []TextEdit
+	// leaves are the names of the recipes that contributed to the edit
+	leaves map[string]bool
+}
+
+// NewServer creates a server offering every recipe of registry that needs no options
+func NewServer(registry *recipe.Registry) *Server {
+	s := &Server{documents: make(map[string]*document)}
+
+	seen := make(map[string]bool)
+	for _, name := range registry.Names() {
+		r, err := registry.New(name, nil)
+		if err != nil {
+			continue // Recipes with required options cannot be offered without configuration
+		}
+		s.recipes = append(s.recipes, r)
+
+		// Composite recipes share leaves; each leaf is searched for once
+		for _, leaf := range recipe.Leaves(r) {
+			if key := recipe.Identity(leaf); !seen[key] {
+				seen[key] = true
+				s.leaves = append(s.leaves, leaf)
+			}
+		}
+	}
+	return s
+}
+
+// Serve handles messages from r and writes responses and notifications to w until the
+// client sends exit, the input ends or ctx is cancelled
+func (s *Server) Serve(ctx context.Context, r io.Reader, w io.Writer) error {
+	s.conn = NewConn(r, w)
+	s.ctx = recipe.NewExecutionContext(ctx)
+
+	for {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+
+		msg, err := s.conn.Read()
+		if errors.Is(err, io.EOF) {
+			return nil
+		}
+		var parseErr *ResponseError
+		if errors.As(err, &parseErr) {
+			if err := s.conn.Write(&Message{ID: nullID(), Error: parseErr}); err != nil {
+				return err
+			}
+			continue
+		}
+		if err != nil {
+			return err
+		}
+
+		if msg.Method == "exit" {
+			if !s.shutdown {
+				return fmt.Errorf("exit received before shutdown")
+			}
+			return nil
+		}
+
+		result, respErr := s.handle(msg)
+		if msg.ID == nil {
+			continue // Notifications have no response
+		}
+
+		response := &Message{ID: msg.ID, Error: respErr}
+		if respErr == nil {
+			if response.Result, err = json.Marshal(result); err != nil {
+				response.Error = &ResponseError{Code: codeInternalError, Message: err.Error()}
+			}
+		}
+		if err := s.conn.Write(response); err != nil {
+			return err
+		}
+	}
+}
+
+func (s *Server) handle(msg *Message) (interface{}, *ResponseError) {
+	switch msg.Method {
+	case "initialize":
+		return map[string]interface{}{
+			"capabilities": map[string]interface{}{
+				"textDocumentSync":   textDocumentSyncFull,
+				"codeActionProvider": true,
+			},
+			"serverInfo": map[string]string{"name": ServerName},
+		}, nil
+
+	case "shutdown":
+		s.shutdown = true
+		return nil, nil
+
+	case "textDocument/didOpen":
+		var params DidOpenTextDocumentParams
+		if err := json.Unmarshal(msg.Params, &params); err != nil {
+			return nil, invalidParams(err)
+		}
+		s.update(params.TextDocument.URI, params.TextDocument.Text)
+		return nil, nil
+
+	case "textDocument/didChange":
+		var params DidChangeTextDocumentParams
+		if err := json.Unmarshal(msg.Params, &params); err != nil {
+			return nil, invalidParams(err)
+		}
+		// With full sync the last change holds the complete document
+		if n := len(params.ContentChanges); n > 0 {
+			s.update(params.TextDocument.URI, params.ContentChanges[n-1].Text)
+		}
+		return nil, nil
+
+	case "textDocument/didClose":
+		var params DidCloseTextDocumentParams
+		if err := json.Unmarshal(msg.Params, &params); err != nil {
+			return nil, invalidParams(err)
+		}
+		delete(s.documents, params.TextDocument.URI)
+		s.publish(params.TextDocument.URI, []Diagnostic{})
+		return nil, nil
+
+	case "textDocument/codeAction":
+		var params CodeActionParams
+		if err := json.Unmarshal(msg.Params, &params); err != nil {
+			return nil, invalidParams(err)
+		}
+		return s.codeActions(params), nil
+	}
+
+	if msg.ID != nil {
+		return nil, &ResponseError{Code: codeMethodNotFound, Message: "method not found: " + msg.Method}
+	}
+	return nil, nil
+}
+
+// update analyzes the new text of a document and publishes its diagnostics
+func (s *Server) update(uri, text string) {
+	doc := &document{path: uriPath(uri), text: text}
+	s.analyze(doc)
+	s.documents[uri] = doc
+	s.publish(uri, doc.diagnostics)
+}
+
+// analyze finds the changes every leaf recipe would make to the document, reported as
+// diagnostics against the document itself, and the edit of every applicable recipe
+func (s *Server) analyze(doc *document) {
+	doc.diagnostics = []Diagnostic{}
+
+	source, err := java.ParseSource(doc.path, doc.text)
+	if err != nil {
+		return // Documents that do not parse have nothing to offer
+	}
+
+	// Leaves with other options, such as the steps of a Java version chain, may change the
+	// same lines; each line is reported once per recipe name
+	type reported struct {
+		code  string
+		lines Range
+	}
+	seen := make(map[reported]bool)
+	for _, leaf := range s.leaves {
+		_, results, err := recipe.Apply(leaf, source, s.ctx)
+		if err != nil {
+			continue
+		}
+		for _, result := range results {
+			for _, edit := range textEdits(result.Before, result.After) {
+				key := reported{code: leaf.GetName(), lines: edit.Range}
+				if seen[key] {
+					continue
+				}
+				seen[key] = true
+				doc.diagnostics = append(doc.diagnostics, Diagnostic{
+					Range:    edit.Range,
+					Severity: SeverityWarning,
+					Code:     leaf.GetName(),
+					Source:   ServerName,
+					Message:  leaf.GetDisplayName(),
+				})
+			}
+		}
+	}
+
+	for _, r := range s.recipes {
+		transformed, results, err := recipe.Apply(r, source, s.ctx)
+		if err != nil || transformed.GetContent() == doc.text {
+			continue
+		}
+
+		f := fix{recipe: r, edits: textEdits(doc.text, transformed.GetContent()), leaves: make(map[string]bool)}
+		for _, result := range results {
+			if result.Changed() {
+				f.leaves[result.Recipe.GetName()] = true
+			}
+		}
+		doc.fixes = append(doc.fixes, f)
+	}
+}
+
+func (s *Server) codeActions(params CodeActionParams) []CodeAction {
+	actions := []CodeAction{}
+	doc, ok := s.documents[params.TextDocument.URI]
+	if !ok {
+		return actions
+	}
+
+	for _, f := range doc.fixes {
+		action := CodeAction{
+			Title: f.recipe.GetDisplayName(),
+			Kind:  "quickfix",
+			Edit: &WorkspaceEdit{Changes: map[string][]TextEdit{
+				params.TextDocument.URI: f.edits,
+			}},
+		}
+		for _, diagnostic := range params.Context.Diagnostics {
+			if diagnostic.Source == ServerName && f.leaves[diagnostic.Code] {
+				action.Diagnostics = append(action.Diagnostics, diagnostic)
+			}
+		}
+		actions = append(actions, action)
+	}
+	return actions
+}
+
+func (s *Server) publish(uri string, diagnostics []Diagnostic) {
+	// A client that went away is noticed by the next read
+	s.conn.Notify("textDocument/publishDiagnostics", PublishDiagnosticsParams{URI: uri, Diagnostics: diagnostics})
+}
+
+// textEdits converts the line diff between before and after into edits of before
+func textEdits(before, after string) []TextEdit {
+	var edits []TextEdit
+	for _, hunk := range diff.Hunks(before, after, 0) {
+		// Hunks without old lines insert after OldStart; others replace from OldStart
+		start := hunk.OldStart
+		if hunk.OldLines > 0 {
+			start--
+		}
+
+		var text strings.Builder
+		for _, line := range hunk.Lines {
+			if line.Op == diff.Insert {
+				text.WriteString(line.Text)
+			}
+		}
+		edits = append(edits, TextEdit{
+			Range: Range{
+				Start: Position{Line: start},
+				End:   Position{Line: start + hunk.OldLines},
+			},
+			NewText: text.String(),
+		})
+	}
+	return edits
+}
+
+// uriPath returns the file system path of a file URI, or the URI itself otherwise
+func uriPath(uri string) string {
+	u, err := url.Parse(uri)
+	if err != nil || u.Scheme != "file" {
+		return uri
+	}
+	return u.Path
+}
+
+func invalidParams(err error) *ResponseError {
+	return &ResponseError{Code: codeInvalidParams, Message: err.Error()}
+}
+
+func nullID() *json.RawMessage {
+	id := json.RawMessage("null")
+	return &id
+}
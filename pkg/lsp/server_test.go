@@ -0,0 +1,275 @@
+package lsp
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"strconv"
+	"strings"
+	"testing"
+
+	"rewrite-migrate-java/pkg/diff"
+	"rewrite-migrate-java/pkg/migrate"
+)
+
+const base64Source = `package com.example;
+
+import sun.misc.BASE64Encoder;
+
+public class Example {
+    public String encode(byte[] data) {
+        return new BASE64Encoder().encode(data);
+    }
+}
+`
+
+// client is an in-process LSP client connected to a running server
+type client struct {
+	t      *testing.T
+	conn   *Conn
+	nextID int
+	// notifications received while waiting for responses
+	notifications []*Message
+	done          chan error
+}
+
+func startServer(t *testing.T) *client {
+	t.Helper()
+	serverIn, clientOut := io.Pipe()
+	clientIn, serverOut := io.Pipe()
+
+	c := &client{t: t, conn: NewConn(clientIn, clientOut), done: make(chan error, 1)}
+	go func() {
+		err := NewServer(migrate.NewRegistry()).Serve(context.Background(), serverIn, serverOut)
+		serverOut.Close()
+		c.done <- err
+	}()
+	return c
+}
+
+func (c *client) request(method string, params, result interface{}) {
+	c.t.Helper()
+	c.nextID++
+	id := json.RawMessage(strconv.Itoa(c.nextID))
+	if err := c.conn.Write(&Message{ID: &id, Method: method, Params: mustMarshal(c.t, params)}); err != nil {
+		c.t.Fatalf("Failed to send %s: %v", method, err)
+	}
+
+	for {
+		msg, err := c.conn.Read()
+		if err != nil {
+			c.t.Fatalf("Failed to read response to %s: %v", method, err)
+		}
+		if msg.ID == nil {
+			c.notifications = append(c.notifications, msg)
+			continue
+		}
+		if msg.Error != nil {
+			c.t.Fatalf("Request %s failed: %v", method, msg.Error)
+		}
+		if result != nil {
+			if err := json.Unmarshal(msg.Result, result); err != nil {
+				c.t.Fatalf("Failed to decode %s result: %v", method, err)
+			}
+		}
+		return
+	}
+}
+
+func (c *client) notify(method string, params interface{}) {
+	c.t.Helper()
+	if err := c.conn.Notify(method, params); err != nil {
+		c.t.Fatalf("Failed to send %s: %v", method, err)
+	}
+}
+
+// diagnostics waits for the next published diagnostics
+func (c *client) diagnostics() PublishDiagnosticsParams {
+	c.t.Helper()
+	for {
+		var msg *Message
+		if len(c.notifications) > 0 {
+			msg, c.notifications = c.notifications[0], c.notifications[1:]
+		} else {
+			var err error
+			if msg, err = c.conn.Read(); err != nil {
+				c.t.Fatalf("Failed to read diagnostics: %v", err)
+			}
+		}
+		if msg.Method != "textDocument/publishDiagnostics" {
+			continue
+		}
+		var params PublishDiagnosticsParams
+		if err := json.Unmarshal(msg.Params, &params); err != nil {
+			c.t.Fatalf("Failed to decode diagnostics: %v", err)
+		}
+		return params
+	}
+}
+
+func mustMarshal(t *testing.T, v interface{}) json.RawMessage {
+	t.Helper()
+	data, err := json.Marshal(v)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return data
+}
+
+// applyEdits applies line-based edits to text, as an editor would
+func applyEdits(text string, edits []TextEdit) string {
+	lines := diff.SplitLines(text)
+	var sb strings.Builder
+	next := 0
+	for _, edit := range edits {
+		for ; next < edit.Range.Start.Line; next++ {
+			sb.WriteString(lines[next])
+		}
+		sb.WriteString(edit.NewText)
+		next = edit.Range.End.Line
+	}
+	for ; next < len(lines); next++ {
+		sb.WriteString(lines[next])
+	}
+	return sb.String()
+}
+
+func TestServerPublishesDiagnosticsAndCodeActions(t *testing.T) {
+	c := startServer(t)
+	uri := "file:///project/src/main/java/com/example/Example.java"
+
+	var initResult struct {
+		Capabilities struct {
+			TextDocumentSync   int  `json:"textDocumentSync"`
+			CodeActionProvider bool `json:"codeActionProvider"`
+		} `json:"capabilities"`
+	}
+	c.request("initialize", map[string]interface{}{"capabilities": map[string]interface{}{}}, &initResult)
+	if initResult.Capabilities.TextDocumentSync != textDocumentSyncFull || !initResult.Capabilities.CodeActionProvider {
+		t.Fatalf("Unexpected capabilities %+v", initResult.Capabilities)
+	}
+	c.notify("initialized", map[string]interface{}{})
+
+	c.notify("textDocument/didOpen", DidOpenTextDocumentParams{TextDocument: TextDocumentItem{
+		URI: uri, LanguageID: "java", Version: 1, Text: base64Source,
+	}})
+	published := c.diagnostics()
+	if published.URI != uri {
+		t.Fatalf("Unexpected diagnostics URI %s", published.URI)
+	}
+
+	var base64Diagnostics []Diagnostic
+	for _, diagnostic := range published.Diagnostics {
+		if diagnostic.Code == "org.openrewrite.java.migrate.UseJavaUtilBase64" {
+			base64Diagnostics = append(base64Diagnostics, diagnostic)
+		}
+	}
+	if len(base64Diagnostics) == 0 {
+		t.Fatalf("Expected UseJavaUtilBase64 diagnostics, got %+v", published.Diagnostics)
+	}
+	if base64Diagnostics[0].Range.Start.Line != 2 {
+		t.Errorf("Expected the first match on the import line, got %+v", base64Diagnostics[0].Range)
+	}
+
+	var actions []CodeAction
+	params := CodeActionParams{TextDocument: TextDocumentIdentifier{URI: uri}}
+	params.Context.Diagnostics = base64Diagnostics
+	c.request("textDocument/codeAction", params, &actions)
+
+	var base64Action *CodeAction
+	for i := range actions {
+		if strings.Contains(actions[i].Title, "java.util.Base64") {
+			base64Action = &actions[i]
+		}
+	}
+	if base64Action == nil {
+		t.Fatalf("Expected a UseJavaUtilBase64 code action, got %+v", actions)
+	}
+	if len(base64Action.Diagnostics) != len(base64Diagnostics) {
+		t.Errorf("Expected the action to resolve %d diagnostics, got %d", len(base64Diagnostics), len(base64Action.Diagnostics))
+	}
+
+	fixed := applyEdits(base64Source, base64Action.Edit.Changes[uri])
+	if !strings.Contains(fixed, "import java.util.Base64;") || strings.Contains(fixed, "sun.misc") {
+		t.Errorf("Unexpected result of the code action:\n%s", fixed)
+	}
+
+	// Once the fix is applied the recipe no longer matches
+	c.notify("textDocument/didChange", map[string]interface{}{
+		"textDocument":   map[string]interface{}{"uri": uri, "version": 2},
+		"contentChanges": []map[string]string{{"text": fixed}},
+	})
+	for _, diagnostic := range c.diagnostics().Diagnostics {
+		if diagnostic.Code == "org.openrewrite.java.migrate.UseJavaUtilBase64" {
+			t.Errorf("Unexpected diagnostic after the fix: %+v", diagnostic)
+		}
+	}
+
+	c.request("shutdown", nil, nil)
+	c.notify("exit", nil)
+	if err := <-c.done; err != nil {
+		t.Errorf("Serve returned %v", err)
+	}
+}
+
+func TestServerReportsChainedRecipes(t *testing.T) {
+	c := startServer(t)
+	uri := "file:///project/pom.xml"
+	pom := `<project>
+  <properties>
+    <maven.compiler.source>8</maven.compiler.source>
+  </properties>
+</project>
+`
+
+	c.request("initialize", map[string]interface{}{"capabilities": map[string]interface{}{}}, nil)
+	c.notify("textDocument/didOpen", DidOpenTextDocumentParams{TextDocument: TextDocumentItem{
+		URI: uri, LanguageID: "xml", Version: 1, Text: pom,
+	}})
+	published := c.diagnostics()
+
+	// Every step of the Java version chain changes the same line, which is reported once
+	var upgrades int
+	for _, diagnostic := range published.Diagnostics {
+		if diagnostic.Code == "org.openrewrite.java.migrate.UpgradeJavaVersion" {
+			upgrades++
+		}
+	}
+	if upgrades != 1 {
+		t.Fatalf("Expected one UpgradeJavaVersion diagnostic, got %+v", published.Diagnostics)
+	}
+
+	var actions []CodeAction
+	params := CodeActionParams{TextDocument: TextDocumentIdentifier{URI: uri}}
+	params.Context.Diagnostics = published.Diagnostics
+	c.request("textDocument/codeAction", params, &actions)
+	if len(actions) == 0 {
+		t.Fatal("Expected code actions for the Java 8 POM")
+	}
+	for _, action := range actions {
+		if len(action.Diagnostics) == 0 {
+			t.Errorf("Expected the action %q to resolve a published diagnostic", action.Title)
+		}
+	}
+}
+
+func TestServerRejectsUnknownRequests(t *testing.T) {
+	c := startServer(t)
+
+	id := json.RawMessage("1")
+	if err := c.conn.Write(&Message{ID: &id, Method: "workspace/unknown"}); err != nil {
+		t.Fatal(err)
+	}
+	msg, err := c.conn.Read()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if msg.Error == nil || msg.Error.Code != codeMethodNotFound {
+		t.Errorf("Expected a method not found error, got %+v", msg)
+	}
+
+	c.notify("exit", nil)
+	if err := <-c.done; err == nil {
+		t.Error("Expected an error for exit without shutdown")
+	}
+}
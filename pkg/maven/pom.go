@@ -0,0 +1,418 @@
+package maven
+
+import (
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+
+	"rewrite-migrate-java/pkg/textfile"
+)
+
+// Element is an XML element of a POM together with its position in the original text
+type Element struct {
+	Name     string
+	Parent   *Element
+	Children []*Element
+
+	// start and end enclose the whole element, tags included
+	start, end int
+	// textStart and textEnd enclose the text of an element without child elements,
+	// surrounding whitespace excluded
+	textStart, textEnd int
+	selfClosing        bool
+	text               string
+}
+
+// Child returns the first child element named name, or nil
+func (e *Element) Child(name string) *Element {
+	if e == nil {
+		return nil
+	}
+	for _, child := range e.Children {
+		if child.Name == name {
+			return child
+		}
+	}
+	return nil
+}
+
+// ChildrenNamed returns every child element named name
+func (e *Element) ChildrenNamed(name string) []*Element {
+	if e == nil {
+		return nil
+	}
+	var children []*Element
+	for _, child := range e.Children {
+		if child.Name == name {
+			children = append(children, child)
+		}
+	}
+	return children
+}
+
+// Find follows a path of child element names and returns the element it ends at, or nil
+func (e *Element) Find(names ...string) *Element {
+	for _, name := range names {
+		e = e.Child(name)
+	}
+	return e
+}
+
+// Text returns the trimmed text of the element
+func (e *Element) Text() string {
+	if e == nil {
+		return ""
+	}
+	return e.text
+}
+
+// Property is a property declared in <properties>
+type Property struct {
+	Name    string
+	Value   string
+	Element *Element
+}
+
+// Dependency is a <dependency> of dependencies or dependencyManagement
+type Dependency struct {
+	GroupID    string
+	ArtifactID string
+	Version    string
+	Scope      string
+	Element    *Element
+}
+
+// Plugin is a <plugin> of the build plugins or pluginManagement
+type Plugin struct {
+	GroupID    string
+	ArtifactID string
+	Version    string
+	// Managed is set for plugins declared in pluginManagement
+	Managed bool
+	Element *Element
+}
+
+// Configurations returns the plugin configuration and the configuration of each of its executions
+func (p Plugin) Configurations() []*Element {
+	var configurations []*Element
+	if configuration := p.Element.Child("configuration"); configuration != nil {
+		configurations = append(configurations, configuration)
+	}
+	for _, execution := range p.Element.Find("executions").ChildrenNamed("execution") {
+		if configuration := execution.Child("configuration"); configuration != nil {
+			configurations = append(configurations, configuration)
+		}
+	}
+	return configurations
+}
+
+// Parent is the <parent> of a POM
+type Parent struct {
+	GroupID    string
+	ArtifactID string
+	Version    string
+	// RelativePath is where the parent POM is looked up first, relative to the directory of
+	// the POM. It is ../pom.xml unless set, and empty when the lookup is disabled.
+	RelativePath string
+	Element      *Element
+}
+
+// Model holds the parts of a POM a profile can also declare
+type Model struct {
+	Properties           []Property
+	Dependencies         []Dependency
+	DependencyManagement []Dependency
+	Plugins              []Plugin
+}
+
+// Property returns the property of the model named name
+func (m Model) Property(name string) (Property, bool) {
+	for _, property := range m.Properties {
+		if property.Name == name {
+			return property, true
+		}
+	}
+	return Property{}, false
+}
+
+// Profile is a <profile> of a POM
+type Profile struct {
+	ID string
+	Model
+	Element *Element
+}
+
+// POM is a parsed pom.xml. Edits only replace the text they change, so the formatting,
+// comments and order of everything else are kept as they are.
+type POM struct {
+	// GroupID and Version are inherited from the parent when the project does not set them
+	GroupID    string
+	ArtifactID string
+	Version    string
+	Parent     *Parent
+
+	Model
+	Profiles []Profile
+	Root     *Element
+
+	content string
+	edits   []edit
+}
+
+// edit replaces content[start:end] with text
+type edit struct {
+	start, end int
+	text       string
+}
+
+// Parse parses the content of a pom.xml
+func Parse(content string) (*POM, error) {
+	root, err := parseElements(content)
+	if err != nil {
+		return nil, err
+	}
+	if root.Name != "project" {
+		return nil, fmt.Errorf("root element is <%s>, expected <project>", root.Name)
+	}
+
+	pom := &POM{
+		GroupID:    root.Child("groupId").Text(),
+		ArtifactID: root.Child("artifactId").Text(),
+		Version:    root.Child("version").Text(),
+		Root:       root,
+		content:    content,
+		Model:      readModel(root),
+	}
+	if element := root.Child("parent"); element != nil {
+		pom.Parent = &Parent{
+			GroupID:      element.Child("groupId").Text(),
+			ArtifactID:   element.Child("artifactId").Text(),
+			Version:      element.Child("version").Text(),
+			RelativePath: "../pom.xml",
+			Element:      element,
+		}
+		if relativePath := element.Child("relativePath"); relativePath != nil {
+			pom.Parent.RelativePath = relativePath.Text()
+		}
+		if pom.GroupID == "" {
+			pom.GroupID = pom.Parent.GroupID
+		}
+		if pom.Version == "" {
+			pom.Version = pom.Parent.Version
+		}
+	}
+	for _, element := range root.Find("profiles").ChildrenNamed("profile") {
+		pom.Profiles = append(pom.Profiles, Profile{
+			ID:      element.Child("id").Text(),
+			Model:   readModel(element),
+			Element: element,
+		})
+	}
+	return pom, nil
+}
+
+// Models returns the model of the project followed by the model of each profile
+func (p *POM) Models() []Model {
+	models := []Model{p.Model}
+	for _, profile := range p.Profiles {
+		models = append(models, profile.Model)
+	}
+	return models
+}
+
+// SetText replaces the text of an element that has no child elements
+func (p *POM) SetText(e *Element, text string) {
+	escaped := escape(text)
+	if e.selfClosing {
+		p.replace(e.start, e.end, "<"+e.Name+">"+escaped+"</"+e.Name+">")
+	} else {
+		p.replace(e.textStart, e.textEnd, escaped)
+	}
+	e.text = text
+}
+
+// ReplaceElement replaces e, tags included, with an element named name holding text
+func (p *POM) ReplaceElement(e *Element, name, text string) {
+	p.replace(e.start, e.end, "<"+name+">"+escape(text)+"</"+name+">")
+}
+
+// AddChild adds an element named name holding text after the last child element of parent.
+// When that child is on a line of its own, so is the new element, with the same indentation.
+// parent must have a child element.
+func (p *POM) AddChild(parent *Element, name, text string) {
+	last := parent.Children[len(parent.Children)-1]
+	lineStart := strings.LastIndexByte(p.content[:last.start], '\n') + 1
+	separator := p.content[lineStart:last.start]
+	if strings.TrimLeft(separator, " \t") != "" {
+		separator = ""
+	} else if lineStart > 1 && p.content[lineStart-2] == '\r' {
+		separator = "\r\n" + separator
+	} else {
+		separator = "\n" + separator
+	}
+	p.replace(last.end, last.end, separator+"<"+name+">"+escape(text)+"</"+name+">")
+}
+
+// Remove removes e. When e is alone on its line, the whole line goes with it.
+func (p *POM) Remove(e *Element) {
+	start, end := e.start, e.end
+	for start > 0 && (p.content[start-1] == ' ' || p.content[start-1] == '\t') {
+		start--
+	}
+	rest := len(p.content[end:]) - len(strings.TrimLeft(p.content[end:], " \t\r"))
+	if (start == 0 || p.content[start-1] == '\n') && end+rest < len(p.content) && p.content[end+rest] == '\n' {
+		end += rest + 1
+	} else {
+		start = e.start
+	}
+	p.replace(start, end, "")
+}
+
+// References reports whether the POM refers to the property name with a ${name} placeholder
+func (p *POM) References(name string) bool {
+	return strings.Contains(p.content, "${"+name+"}")
+}
+
+// String returns the POM with every edit applied
+func (p *POM) String() string {
+	edits := append([]edit{}, p.edits...)
+	sort.SliceStable(edits, func(i, j int) bool {
+		return edits[i].start > edits[j].start
+	})
+
+	content := p.content
+	for _, e := range edits {
+		content = content[:e.start] + e.text + content[e.end:]
+	}
+	return content
+}
+
+// replace records an edit, superseding an earlier edit of the same range. Edits of
+// overlapping ranges are not supported.
+func (p *POM) replace(start, end int, text string) {
+	for i, e := range p.edits {
+		if e.start == start && e.end == end {
+			p.edits[i].text = text
+			return
+		}
+	}
+	p.edits = append(p.edits, edit{start: start, end: end, text: text})
+}
+
+func readModel(element *Element) Model {
+	var model Model
+	if properties := element.Child("properties"); properties != nil {
+		for _, property := range properties.Children {
+			model.Properties = append(model.Properties, Property{Name: property.Name, Value: property.Text(), Element: property})
+		}
+	}
+	model.Dependencies = readDependencies(element.Child("dependencies"))
+	model.DependencyManagement = readDependencies(element.Find("dependencyManagement", "dependencies"))
+
+	build := element.Child("build")
+	model.Plugins = append(readPlugins(build.Child("plugins"), false), readPlugins(build.Find("pluginManagement", "plugins"), true)...)
+	return model
+}
+
+func readDependencies(dependencies *Element) []Dependency {
+	var result []Dependency
+	for _, dependency := range dependencies.ChildrenNamed("dependency") {
+		result = append(result, Dependency{
+			GroupID:    dependency.Child("groupId").Text(),
+			ArtifactID: dependency.Child("artifactId").Text(),
+			Version:    dependency.Child("version").Text(),
+			Scope:      dependency.Child("scope").Text(),
+			Element:    dependency,
+		})
+	}
+	return result
+}
+
+func readPlugins(plugins *Element, managed bool) []Plugin {
+	var result []Plugin
+	for _, plugin := range plugins.ChildrenNamed("plugin") {
+		groupID := plugin.Child("groupId").Text()
+		if groupID == "" {
+			groupID = "org.apache.maven.plugins"
+		}
+		result = append(result, Plugin{
+			GroupID:    groupID,
+			ArtifactID: plugin.Child("artifactId").Text(),
+			Version:    plugin.Child("version").Text(),
+			Managed:    managed,
+			Element:    plugin,
+		})
+	}
+	return result
+}
+
+// parseElements builds the element tree of content, recording where each element is
+func parseElements(content string) (*Element, error) {
+	decoder := xml.NewDecoder(strings.NewReader(content))
+	decoder.CharsetReader = textfile.DecodedCharsetReader
+
+	var root, current *Element
+	var text strings.Builder
+	offset := 0
+	for {
+		token, err := decoder.Token()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse POM: %w", err)
+		}
+		next := int(decoder.InputOffset())
+
+		switch t := token.(type) {
+		case xml.StartElement:
+			element := &Element{Name: t.Name.Local, Parent: current, start: offset, textStart: next}
+			if current != nil {
+				current.Children = append(current.Children, element)
+			} else if root == nil {
+				root = element
+			}
+			current = element
+			text.Reset()
+
+		case xml.CharData:
+			text.Write(t)
+
+		case xml.EndElement:
+			if current == nil {
+				return nil, fmt.Errorf("failed to parse POM: unexpected </%s>", t.Name.Local)
+			}
+			current.end = next
+			// A self-closing element ends where it starts
+			current.selfClosing = offset == current.textStart && next == offset
+			if current.selfClosing {
+				current.textStart, current.textEnd = current.end, current.end
+			} else if len(current.Children) == 0 {
+				raw := content[current.textStart:offset]
+				current.textStart += len(raw) - len(strings.TrimLeft(raw, " \t\r\n"))
+				current.textEnd = offset - (len(raw) - len(strings.TrimRight(raw, " \t\r\n")))
+				if current.textEnd < current.textStart {
+					current.textEnd = current.textStart
+				}
+				current.text = strings.TrimSpace(text.String())
+			}
+			current = current.Parent
+			text.Reset()
+		}
+		offset = next
+	}
+
+	if root == nil {
+		return nil, fmt.Errorf("failed to parse POM: no root element")
+	}
+	return root, nil
+}
+
+func escape(text string) string {
+	var sb strings.Builder
+	xml.EscapeText(&sb, []byte(text))
+	return sb.String()
+}
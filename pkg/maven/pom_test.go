@@ -0,0 +1,185 @@
+package maven
+
+import (
+	"strings"
+	"testing"
+)
+
+const pomContent = `<?xml version="1.0" encoding="UTF-8"?>
+<project xmlns="http://maven.apache.org/POM/4.0.0">
+  <!-- keep this comment -->
+  <properties>
+    <java.version>8</java.version>
+    <empty/>
+  </properties>
+  <dependencies>
+    <dependency>
+      <groupId>junit</groupId>
+      <artifactId>junit</artifactId>
+      <version>4.13.2</version>
+      <scope>test</scope>
+    </dependency>
+  </dependencies>
+  <dependencyManagement>
+    <dependencies>
+      <dependency>
+        <groupId>org.slf4j</groupId>
+        <artifactId>slf4j-api</artifactId>
+        <version>2.0.9</version>
+      </dependency>
+    </dependencies>
+  </dependencyManagement>
+  <build>
+    <plugins>
+      <plugin>
+        <artifactId>maven-compiler-plugin</artifactId>
+        <configuration>
+          <source>8</source>
+        </configuration>
+        <executions>
+          <execution>
+            <configuration><target>8</target></configuration>
+          </execution>
+        </executions>
+      </plugin>
+    </plugins>
+    <pluginManagement>
+      <plugins>
+        <plugin>
+          <groupId>org.codehaus.mojo</groupId>
+          <artifactId>exec-maven-plugin</artifactId>
+        </plugin>
+      </plugins>
+    </pluginManagement>
+  </build>
+  <profiles>
+    <profile>
+      <id>legacy</id>
+      <properties>
+        <maven.compiler.source>1.6</maven.compiler.source>
+      </properties>
+    </profile>
+  </profiles>
+</project>
+`
+
+func TestParse(t *testing.T) {
+	pom, err := Parse(pomContent)
+	if err != nil {
+		t.Fatalf("Parse failed: %v", err)
+	}
+
+	if len(pom.Properties) != 2 || pom.Properties[0].Name != "java.version" || pom.Properties[0].Value != "8" {
+		t.Errorf("Unexpected properties %+v", pom.Properties)
+	}
+	if len(pom.Dependencies) != 1 || pom.Dependencies[0].ArtifactID != "junit" || pom.Dependencies[0].Scope != "test" {
+		t.Errorf("Unexpected dependencies %+v", pom.Dependencies)
+	}
+	if len(pom.DependencyManagement) != 1 || pom.DependencyManagement[0].Version != "2.0.9" {
+		t.Errorf("Unexpected dependency management %+v", pom.DependencyManagement)
+	}
+
+	if len(pom.Plugins) != 2 {
+		t.Fatalf("Expected 2 plugins, got %+v", pom.Plugins)
+	}
+	compiler, exec := pom.Plugins[0], pom.Plugins[1]
+	if compiler.GroupID != "org.apache.maven.plugins" || compiler.Managed || len(compiler.Configurations()) != 2 {
+		t.Errorf("Unexpected compiler plugin %+v", compiler)
+	}
+	if exec.ArtifactID != "exec-maven-plugin" || !exec.Managed {
+		t.Errorf("Unexpected managed plugin %+v", exec)
+	}
+
+	if len(pom.Profiles) != 1 || pom.Profiles[0].ID != "legacy" || pom.Profiles[0].Properties[0].Value != "1.6" {
+		t.Errorf("Unexpected profiles %+v", pom.Profiles)
+	}
+	if len(pom.Models()) != 2 {
+		t.Errorf("Expected the project and profile models, got %d", len(pom.Models()))
+	}
+}
+
+func TestSetTextKeepsFormatting(t *testing.T) {
+	pom, err := Parse(pomContent)
+	if err != nil {
+		t.Fatalf("Parse failed: %v", err)
+	}
+
+	pom.SetText(pom.Properties[0].Element, "17")
+	pom.SetText(pom.Properties[1].Element, "a&b")
+	for _, configuration := range pom.Plugins[0].Configurations() {
+		for _, child := range configuration.Children {
+			pom.SetText(child, "17")
+		}
+	}
+	pom.SetText(pom.Profiles[0].Properties[0].Element, "11")
+
+	expected := strings.NewReplacer(
+		"<java.version>8</java.version>", "<java.version>17</java.version>",
+		"<empty/>", "<empty>a&amp;b</empty>",
+		"<source>8</source>", "<source>17</source>",
+		"<target>8</target>", "<target>17</target>",
+		"<maven.compiler.source>1.6</maven.compiler.source>", "<maven.compiler.source>11</maven.compiler.source>",
+	).Replace(pomContent)
+	if got := pom.String(); got != expected {
+		t.Errorf("Expected:\n%s\nGot:\n%s", expected, got)
+	}
+
+	// The edited POM parses back to the new values
+	reparsed, err := Parse(pom.String())
+	if err != nil {
+		t.Fatalf("Parse of the edited POM failed: %v", err)
+	}
+	if reparsed.Properties[1].Value != "a&b" {
+		t.Errorf("Expected the escaped value to round-trip, got %q", reparsed.Properties[1].Value)
+	}
+}
+
+func TestAddChild(t *testing.T) {
+	pom, err := Parse(pomContent)
+	if err != nil {
+		t.Fatalf("Parse failed: %v", err)
+	}
+
+	pom.AddChild(pom.Root.Child("properties"), "maven.compiler.release", "17")
+	configuration := pom.Plugins[0].Configurations()[1]
+	pom.AddChild(configuration, "release", "17")
+
+	expected := strings.NewReplacer(
+		"<empty/>", "<empty/>\n    <maven.compiler.release>17</maven.compiler.release>",
+		"<target>8</target>", "<target>8</target><release>17</release>",
+	).Replace(pomContent)
+	if got := pom.String(); got != expected {
+		t.Errorf("Expected:\n%s\nGot:\n%s", expected, got)
+	}
+}
+
+func TestParseRejectsOtherDocuments(t *testing.T) {
+	if _, err := Parse("<settings/>"); err == nil {
+		t.Error("Expected an error for a document that is not a POM")
+	}
+	if _, err := Parse("<project><build></project>"); err == nil {
+		t.Error("Expected an error for malformed XML")
+	}
+}
+
+func TestParseDeclaredLatin1(t *testing.T) {
+	// textfile has already decoded the content to UTF-8, so the declaration no longer applies
+	content := `<?xml version="1.0" encoding="ISO-8859-1"?>
+<project>
+  <name>Café</name>
+  <properties>
+    <maven.compiler.source>8</maven.compiler.source>
+  </properties>
+</project>
+`
+	pom, err := Parse(content)
+	if err != nil {
+		t.Fatalf("Parse failed: %v", err)
+	}
+	pom.SetText(pom.Properties[0].Element, "17")
+
+	expected := strings.Replace(content, ">8<", ">17<", 1)
+	if got := pom.String(); got != expected {
+		t.Errorf("Expected:\n%s\nGot:\n%s", expected, got)
+	}
+}
@@ -0,0 +1,256 @@
+package maven
+
+import (
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+
+	"rewrite-migrate-java/pkg/recipe"
+	"rewrite-migrate-java/pkg/textfile"
+)
+
+// reactorProperty is the ExecutionContext property holding the reactor of a run
+const reactorProperty = "maven.reactor"
+
+// SetReactor makes r the reactor recipes resolve POM inheritance against
+func SetReactor(ctx *recipe.ExecutionContext, r *Reactor) {
+	ctx.PutProperty(reactorProperty, r)
+}
+
+// ReactorFrom returns the reactor set with SetReactor, or nil
+func ReactorFrom(ctx *recipe.ExecutionContext) *Reactor {
+	value, _ := ctx.GetProperty(reactorProperty)
+	r, _ := value.(*Reactor)
+	return r
+}
+
+var placeholderRegex = regexp.MustCompile(`^\$\{([^}]+)\}$`)
+
+// Placeholder returns the property name of a value that is a single ${name} placeholder
+func Placeholder(value string) (string, bool) {
+	match := placeholderRegex.FindStringSubmatch(value)
+	if match == nil {
+		return "", false
+	}
+	return strings.TrimSpace(match[1]), true
+}
+
+// Declaration is the property a placeholder resolves to
+type Declaration struct {
+	// Path is the path of the declaring POM in the reactor, empty for a POM of the local repository
+	Path     string
+	Property Property
+}
+
+// Reactor holds the POMs of a multi-module project, keyed by slash-separated path, and
+// resolves their parents and properties. It is safe for concurrent use.
+type Reactor struct {
+	// LocalRepository is the directory parents outside the reactor are read from, usually
+	// ~/.m2/repository. They are only read, never edited. Empty disables the lookup.
+	LocalRepository string
+
+	poms map[string]*POM
+
+	mu    sync.Mutex
+	local map[string]*POM
+}
+
+// NewReactor creates an empty reactor that looks up parents in DefaultLocalRepository
+func NewReactor() *Reactor {
+	return &Reactor{
+		LocalRepository: DefaultLocalRepository(),
+		poms:            make(map[string]*POM),
+		local:           make(map[string]*POM),
+	}
+}
+
+// DefaultLocalRepository returns ~/.m2/repository, or an empty string without a home directory
+func DefaultLocalRepository() string {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return ""
+	}
+	return filepath.Join(home, ".m2", "repository")
+}
+
+// LoadReactor creates a reactor with the POMs at names in fsys. POMs that cannot be read or
+// parsed are left out.
+func LoadReactor(fsys fs.FS, names []string) *Reactor {
+	r := NewReactor()
+	for _, name := range names {
+		content, _, err := textfile.ReadFS(fsys, name)
+		if err != nil {
+			continue
+		}
+		if pom, err := Parse(content); err == nil {
+			r.Add(name, pom)
+		}
+	}
+	return r
+}
+
+// Add adds the POM at the slash-separated name, replacing any POM already there
+func (r *Reactor) Add(name string, pom *POM) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.poms[path.Clean(name)] = pom
+}
+
+// POM returns the POM at name
+func (r *Reactor) POM(name string) (*POM, bool) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	pom, ok := r.poms[path.Clean(name)]
+	return pom, ok
+}
+
+// Inheritors returns the paths of the POMs that inherit from the POM at name, directly or
+// through other parents, in lexical order
+func (r *Reactor) Inheritors(name string) []string {
+	name = path.Clean(name)
+	var inheritors []string
+	for _, candidate := range r.paths() {
+		if candidate == name {
+			continue
+		}
+		pom, _ := r.POM(candidate)
+		r.walk(candidate, pom, func(parentPath string, _ *POM) bool {
+			if parentPath == candidate {
+				return true
+			}
+			if parentPath == name {
+				inheritors = append(inheritors, candidate)
+				return false
+			}
+			return true
+		})
+	}
+	return inheritors
+}
+
+// Resolve returns the declaration of the property name as seen from the POM at name: the
+// POM itself first, then each of its parents. A property whose value is another placeholder
+// is followed to the property that holds the value.
+func (r *Reactor) Resolve(name, property string) (Declaration, bool) {
+	return r.resolve(path.Clean(name), property, map[string]bool{})
+}
+
+func (r *Reactor) resolve(name, property string, seen map[string]bool) (Declaration, bool) {
+	if seen[property] {
+		return Declaration{}, false
+	}
+	seen[property] = true
+
+	pom, ok := r.POM(name)
+	if !ok {
+		return Declaration{}, false
+	}
+
+	var declaration Declaration
+	found := false
+	r.walk(name, pom, func(pomPath string, pom *POM) bool {
+		if p, ok := pom.Property(property); ok {
+			declaration, found = Declaration{Path: pomPath, Property: p}, true
+			return false
+		}
+		return true
+	})
+	if !found {
+		return Declaration{}, false
+	}
+
+	// Placeholders are interpolated in the context of the inheriting POM, like Maven does
+	if next, ok := Placeholder(declaration.Property.Value); ok {
+		return r.resolve(name, next, seen)
+	}
+	return declaration, true
+}
+
+// walk calls fn with the POM at name and then with each of its parents until fn returns
+// false or the chain ends
+func (r *Reactor) walk(name string, pom *POM, fn func(name string, pom *POM) bool) {
+	seen := make(map[*POM]bool)
+	for pom != nil && !seen[pom] {
+		seen[pom] = true
+		if !fn(name, pom) {
+			return
+		}
+		name, pom = r.parent(name, pom)
+	}
+}
+
+// parent finds the parent of the POM at name: at its relative path, then anywhere in the
+// reactor by coordinates, then in the local repository
+func (r *Reactor) parent(name string, pom *POM) (string, *POM) {
+	parent := pom.Parent
+	if parent == nil {
+		return "", nil
+	}
+
+	if name != "" && parent.RelativePath != "" {
+		candidate := path.Join(path.Dir(name), filepath.ToSlash(parent.RelativePath))
+		if !strings.HasSuffix(candidate, ".xml") {
+			candidate = path.Join(candidate, "pom.xml")
+		}
+		if pom, ok := r.POM(candidate); ok && parent.matches(pom) {
+			return candidate, pom
+		}
+	}
+
+	for _, candidate := range r.paths() {
+		if pom, _ := r.POM(candidate); parent.matches(pom) {
+			return candidate, pom
+		}
+	}
+
+	return "", r.localPOM(parent)
+}
+
+// localPOM reads the POM of parent from the local repository, or returns nil
+func (r *Reactor) localPOM(parent *Parent) *POM {
+	if r.LocalRepository == "" || parent.GroupID == "" || parent.ArtifactID == "" || parent.Version == "" {
+		return nil
+	}
+	key := parent.GroupID + ":" + parent.ArtifactID + ":" + parent.Version
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if pom, ok := r.local[key]; ok {
+		return pom
+	}
+
+	name := filepath.Join(r.LocalRepository, filepath.FromSlash(strings.ReplaceAll(parent.GroupID, ".", "/")),
+		parent.ArtifactID, parent.Version, parent.ArtifactID+"-"+parent.Version+".pom")
+	var pom *POM
+	if data, err := os.ReadFile(name); err == nil {
+		content, _ := textfile.Decode(data)
+		pom, _ = Parse(content)
+	}
+	r.local[key] = pom
+	return pom
+}
+
+func (r *Reactor) paths() []string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	paths := make([]string, 0, len(r.poms))
+	for name := range r.poms {
+		paths = append(paths, name)
+	}
+	sort.Strings(paths)
+	return paths
+}
+
+// matches reports whether pom has the coordinates of p. Versions set by a placeholder, such
+// as ${revision}, are not compared.
+func (p *Parent) matches(pom *POM) bool {
+	if pom.GroupID != p.GroupID || pom.ArtifactID != p.ArtifactID {
+		return false
+	}
+	return p.Version == pom.Version || strings.Contains(p.Version, "${") || strings.Contains(pom.Version, "${")
+}
@@ -0,0 +1,142 @@
+package maven
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func mustParse(t *testing.T, content string) *POM {
+	t.Helper()
+	pom, err := Parse(content)
+	if err != nil {
+		t.Fatalf("Parse failed: %v", err)
+	}
+	return pom
+}
+
+func TestReactorResolvesThroughParents(t *testing.T) {
+	r := NewReactor()
+	r.LocalRepository = t.TempDir()
+
+	corporate := `<project>
+  <groupId>com.example</groupId>
+  <artifactId>corporate</artifactId>
+  <version>3</version>
+  <properties>
+    <jdk>11</jdk>
+  </properties>
+</project>`
+	dir := filepath.Join(r.LocalRepository, "com", "example", "corporate", "3")
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "corporate-3.pom"), []byte(corporate), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	r.Add("build/parent/pom.xml", mustParse(t, `<project>
+  <parent>
+    <groupId>com.example</groupId>
+    <artifactId>corporate</artifactId>
+    <version>3</version>
+  </parent>
+  <artifactId>parent</artifactId>
+  <version>1.0</version>
+  <properties>
+    <java.version>8</java.version>
+  </properties>
+</project>`))
+	r.Add("app/pom.xml", mustParse(t, `<project>
+  <parent>
+    <groupId>com.example</groupId>
+    <artifactId>parent</artifactId>
+    <version>1.0</version>
+    <relativePath>../build/parent</relativePath>
+  </parent>
+  <artifactId>app</artifactId>
+  <properties>
+    <maven.compiler.release>${java.version}</maven.compiler.release>
+  </properties>
+</project>`))
+	r.Add("lib/pom.xml", mustParse(t, `<project>
+  <groupId>com.example</groupId>
+  <artifactId>lib</artifactId>
+  <version>1.0</version>
+</project>`))
+
+	declaration, ok := r.Resolve("app/pom.xml", "maven.compiler.release")
+	if !ok || declaration.Path != "build/parent/pom.xml" || declaration.Property.Name != "java.version" {
+		t.Errorf("Expected java.version of the parent POM, got %+v", declaration)
+	}
+
+	declaration, ok = r.Resolve("app/pom.xml", "jdk")
+	if !ok || declaration.Path != "" || declaration.Property.Value != "11" {
+		t.Errorf("Expected jdk of the local repository parent, got %+v", declaration)
+	}
+
+	if _, ok := r.Resolve("lib/pom.xml", "java.version"); ok {
+		t.Error("Expected java.version not to resolve for a POM without parent")
+	}
+
+	if inheritors := r.Inheritors("build/parent/pom.xml"); !reflect.DeepEqual(inheritors, []string{"app/pom.xml"}) {
+		t.Errorf("Unexpected inheritors %v", inheritors)
+	}
+}
+
+func TestReactorStopsAtCyclicPlaceholders(t *testing.T) {
+	r := NewReactor()
+	r.LocalRepository = ""
+	r.Add("pom.xml", mustParse(t, `<project>
+  <properties>
+    <a>${b}</a>
+    <b>${a}</b>
+  </properties>
+</project>`))
+
+	if _, ok := r.Resolve("pom.xml", "a"); ok {
+		t.Error("Expected cyclic placeholders not to resolve")
+	}
+}
+
+func TestReactorDecodesLocalRepositoryPOMs(t *testing.T) {
+	corporate := func(name string) string {
+		return `<?xml version="1.0" encoding="ISO-8859-1"?>
+<project>
+  <groupId>com.example</groupId>
+  <artifactId>corporate</artifactId>
+  <version>3</version>
+  <name>` + name + `</name>
+  <properties>
+    <jdk>11</jdk>
+  </properties>
+</project>`
+	}
+	for encoding, data := range map[string][]byte{
+		// Société in ISO-8859-1
+		"latin1": []byte(corporate("Soci\xe9t\xe9")),
+		"BOM":    append([]byte("\xef\xbb\xbf"), corporate("Société")...),
+	} {
+		r := NewReactor()
+		r.LocalRepository = t.TempDir()
+		dir := filepath.Join(r.LocalRepository, "com", "example", "corporate", "3")
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(filepath.Join(dir, "corporate-3.pom"), data, 0644); err != nil {
+			t.Fatal(err)
+		}
+		r.Add("pom.xml", mustParse(t, `<project>
+  <parent>
+    <groupId>com.example</groupId>
+    <artifactId>corporate</artifactId>
+    <version>3</version>
+  </parent>
+</project>`))
+
+		if declaration, ok := r.Resolve("pom.xml", "jdk"); !ok || declaration.Property.Value != "11" {
+			t.Errorf("Expected jdk of the %s parent, got %+v", encoding, declaration)
+		}
+	}
+}
@@ -1,7 +1,9 @@
 package migrate
 
 import (
+	"fmt"
 	"regexp"
+	"strconv"
 	"strings"
 	"time"
 
@@ -23,6 +25,113 @@ func replacePackageReferences(content, oldPackage, newPackage string) string {
 	return content
 }
 
+// SupportedJavaVersions lists the Java releases that have a migration recipe, oldest first
+var SupportedJavaVersions = []int{6, 7, 8, 11, 17, 21, 25}
+
+// NewJavaVersionMigration returns the migration recipe for a target Java release. Each
+// release runs the migrations of every earlier release before its own.
+func NewJavaVersionMigration(version int) (recipe.Recipe, error) {
+	switch version {
+	case 6:
+		return NewUpgradeToJava6(), nil
+	case 7:
+		return NewUpgradeToJava7(), nil
+	case 8:
+		return NewUpgradeToJava8(), nil
+	case 11:
+		return NewJava8ToJava11(), nil
+	case 17:
+		return NewUpgradeToJava17(), nil
+	case 21:
+		return NewUpgradeToJava21(), nil
+	case 25:
+		return NewUpgradeToJava25(), nil
+	}
+
+	supported := make([]string, len(SupportedJavaVersions))
+	for i, v := range SupportedJavaVersions {
+		supported[i] = strconv.Itoa(v)
+	}
+	return nil, fmt.Errorf("unsupported Java version %d, expected one of %s", version, strings.Join(supported, ", "))
+}
+
+// chain returns the recipes of a migration to version that builds on the migration to an
+// earlier release: the recipes of previous, then one UpgradeJavaVersion to version, then
+// recipes. The build file upgrades of previous are left out, so build files are upgraded
+// once, straight to version.
+func chain(previous recipe.Recipe, version int, recipes ...recipe.Recipe) []recipe.Recipe {
+	var chained []recipe.Recipe
+	for _, leaf := range recipe.Leaves(previous) {
+		if _, upgrade := leaf.(*UpgradeJavaVersion); !upgrade {
+			chained = append(chained, leaf)
+		}
+	}
+	chained = append(chained, NewUpgradeJavaVersion(version))
+	return append(chained, recipes...)
+}
+
+// UpgradeToJava6 provides a composite recipe for migrating to Java 6
+type UpgradeToJava6 struct {
+	*recipe.CompositeRecipe
+}
+
+// NewUpgradeToJava6 creates a new Java 6 migration recipe
+func NewUpgradeToJava6() *UpgradeToJava6 {
+	return &UpgradeToJava6{
+		CompositeRecipe: &recipe.CompositeRecipe{
+			BaseRecipe: &recipe.BaseRecipe{
+				Name:            "org.openrewrite.java.migrate.UpgradeToJava6",
+				DisplayName:     "Migrate to Java 6",
+				Description:     "Applies changes commonly needed when upgrading to Java 6.",
+				EstimatedEffort: 5 * time.Minute,
+			},
+			Recipes: []recipe.Recipe{
+				NewUpgradeJavaVersion(6),
+			},
+		},
+	}
+}
+
+// UpgradeToJava7 provides a composite recipe for migrating to Java 7
+type UpgradeToJava7 struct {
+	*recipe.CompositeRecipe
+}
+
+// NewUpgradeToJava7 creates a new Java 7 migration recipe
+func NewUpgradeToJava7() *UpgradeToJava7 {
+	return &UpgradeToJava7{
+		CompositeRecipe: &recipe.CompositeRecipe{
+			BaseRecipe: &recipe.BaseRecipe{
+				Name:            "org.openrewrite.java.migrate.UpgradeToJava7",
+				DisplayName:     "Migrate to Java 7",
+				Description:     "Applies changes commonly needed when upgrading to Java 7, including all Java 6 changes.",
+				EstimatedEffort: 5 * time.Minute,
+			},
+			Recipes: chain(NewUpgradeToJava6(), 7),
+		},
+	}
+}
+
+// UpgradeToJava8 provides a composite recipe for migrating to Java 8
+type UpgradeToJava8 struct {
+	*recipe.CompositeRecipe
+}
+
+// NewUpgradeToJava8 creates a new Java 8 migration recipe
+func NewUpgradeToJava8() *UpgradeToJava8 {
+	return &UpgradeToJava8{
+		CompositeRecipe: &recipe.CompositeRecipe{
+			BaseRecipe: &recipe.BaseRecipe{
+				Name:            "org.openrewrite.java.migrate.UpgradeToJava8",
+				DisplayName:     "Migrate to Java 8",
+				Description:     "Applies changes commonly needed when upgrading to Java 8, including all Java 7 changes.",
+				EstimatedEffort: 10 * time.Minute,
+			},
+			Recipes: chain(NewUpgradeToJava7(), 8),
+		},
+	}
+}
+
 // Java8ToJava11 provides a composite recipe for migrating from Java 8 to Java 11
 type Java8ToJava11 struct {
 	*recipe.CompositeRecipe
@@ -33,17 +142,17 @@ func NewJava8ToJava11() *Java8ToJava11 {
 	return &Java8ToJava11{
 		CompositeRecipe: &recipe.CompositeRecipe{
 			BaseRecipe: &recipe.BaseRecipe{
+				Name:        "org.openrewrite.java.migrate.Java8toJava11",
 				DisplayName: "Migrate from Java 8 to Java 11",
 				Description: "Migrates Java 8 applications to Java 11, including updating dependencies, " +
 					"replacing deprecated APIs, and handling Java EE to Jakarta EE transitions.",
 				EstimatedEffort: 30 * time.Minute,
 			},
-			Recipes: []recipe.Recipe{
-				NewUpgradeJavaVersion(11),
+			Recipes: chain(NewUpgradeToJava8(), 11,
 				NewUseJavaUtilBase64("sun.misc", false),
 				NewJavaEEToJakartaEE(),
 				NewRemoveDeprecatedAPIs(),
-			},
+			),
 		},
 	}
 }
@@ -58,16 +167,15 @@ func NewUpgradeToJava17() *UpgradeToJava17 {
 	return &UpgradeToJava17{
 		CompositeRecipe: &recipe.CompositeRecipe{
 			BaseRecipe: &recipe.BaseRecipe{
+				Name:        "org.openrewrite.java.migrate.UpgradeToJava17",
 				DisplayName: "Upgrade to Java 17",
-				Description: "Upgrades applications to Java 17, handling deprecated APIs and " +
-					"illegal reflective access issues.",
+				Description: "Upgrades applications to Java 17, including all Java 11 changes, handling " +
+					"deprecated APIs and illegal reflective access issues.",
 				EstimatedEffort: 20 * time.Minute,
 			},
-			Recipes: []recipe.Recipe{
-				NewUpgradeJavaVersion(17),
-				NewUseJavaUtilBase64("sun.misc", false),
+			Recipes: chain(NewJava8ToJava11(), 17,
 				NewFixReflectiveAccess(),
-			},
+			),
 		},
 	}
 }
@@ -82,17 +190,35 @@ func NewUpgradeToJava21() *UpgradeToJava21 {
 	return &UpgradeToJava21{
 		CompositeRecipe: &recipe.CompositeRecipe{
 			BaseRecipe: &recipe.BaseRecipe{
+				Name:        "org.openrewrite.java.migrate.UpgradeToJava21",
 				DisplayName: "Upgrade to Java 21",
 				Description: "Upgrades applications to Java 21, including all Java 17 changes plus " +
 					"support for sequenced collections and other Java 21 features.",
 				EstimatedEffort: 15 * time.Minute,
 			},
-			Recipes: []recipe.Recipe{
-				NewUpgradeJavaVersion(21),
-				NewUseJavaUtilBase64("sun.misc", false),
-				NewFixReflectiveAccess(),
+			Recipes: chain(NewUpgradeToJava17(), 21,
 				NewSequencedCollectionsMigration(),
+			),
+		},
+	}
+}
+
+// UpgradeToJava25 provides a composite recipe for upgrading to Java 25
+type UpgradeToJava25 struct {
+	*recipe.CompositeRecipe
+}
+
+// NewUpgradeToJava25 creates a new Java 25 upgrade recipe
+func NewUpgradeToJava25() *UpgradeToJava25 {
+	return &UpgradeToJava25{
+		CompositeRecipe: &recipe.CompositeRecipe{
+			BaseRecipe: &recipe.BaseRecipe{
+				Name:            "org.openrewrite.java.migrate.UpgradeToJava25",
+				DisplayName:     "Upgrade to Java 25",
+				Description:     "Upgrades applications to Java 25, including all Java 21 changes.",
+				EstimatedEffort: 15 * time.Minute,
 			},
+			Recipes: chain(NewUpgradeToJava21(), 25),
 		},
 	}
 }
@@ -106,6 +232,7 @@ type JavaEEToJakartaEE struct {
 func NewJavaEEToJakartaEE() *JavaEEToJakartaEE {
 	return &JavaEEToJakartaEE{
 		BaseRecipe: &recipe.BaseRecipe{
+			Name:            "org.openrewrite.java.migrate.jakarta.JavaxMigrationToJakarta",
 			DisplayName:     "Migrate Java EE to Jakarta EE",
 			Description:     "Migrates Java EE dependencies and imports to Jakarta EE equivalents",
 			EstimatedEffort: 10 * time.Minute,
@@ -0,0 +1,74 @@
+package migrate
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"rewrite-migrate-java/pkg/recipe"
+)
+
+// leafNames returns the display names of the leaves of r that are not build file upgrades
+func leafNames(r recipe.Recipe) map[string]bool {
+	names := make(map[string]bool)
+	for _, leaf := range recipe.Leaves(r) {
+		if _, ok := leaf.(*UpgradeJavaVersion); !ok {
+			names[leaf.GetDisplayName()] = true
+		}
+	}
+	return names
+}
+
+func TestJavaVersionMigrationsChainEarlierReleases(t *testing.T) {
+	java11 := leafNames(NewJava8ToJava11())
+	for _, version := range []int{17, 21, 25} {
+		r, err := NewJavaVersionMigration(version)
+		if err != nil {
+			t.Fatalf("NewJavaVersionMigration(%d) failed: %v", version, err)
+		}
+		leaves := leafNames(r)
+		for name := range java11 {
+			if !leaves[name] {
+				t.Errorf("Expected the Java %d migration to include %q", version, name)
+			}
+		}
+	}
+
+	r, _ := NewJavaVersionMigration(17)
+	source := &mockSourceFile{path: "pom.xml", content: "<project>\n  <properties>\n    <maven.compiler.source>6</maven.compiler.source>\n  </properties>\n</project>\n"}
+	result, _, err := recipe.Apply(r, source, recipe.NewExecutionContext(context.Background()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(result.GetContent(), "<maven.compiler.source>17</maven.compiler.source>") {
+		t.Errorf("Expected the build to target Java 17, got:\n%s", result.GetContent())
+	}
+}
+
+func TestJavaVersionMigrationsUpgradeBuildFilesOnce(t *testing.T) {
+	for _, version := range SupportedJavaVersions {
+		r, _ := NewJavaVersionMigration(version)
+		var upgrades []int
+		for _, leaf := range recipe.Leaves(r) {
+			if upgrade, ok := leaf.(*UpgradeJavaVersion); ok {
+				upgrades = append(upgrades, upgrade.Version)
+			}
+		}
+		if len(upgrades) != 1 || upgrades[0] != version {
+			t.Errorf("Expected the Java %d migration to upgrade build files once to %d, got %v", version, version, upgrades)
+		}
+	}
+}
+
+func TestJavaVersionMigrationRejectsUnsupportedVersions(t *testing.T) {
+	for _, version := range SupportedJavaVersions {
+		if _, err := NewJavaVersionMigration(version); err != nil {
+			t.Errorf("Expected Java %d to be supported: %v", version, err)
+		}
+	}
+	for _, version := range []int{0, 5, 9, 16, 22, 26} {
+		if _, err := NewJavaVersionMigration(version); err == nil {
+			t.Errorf("Expected Java %d to be rejected", version)
+		}
+	}
+}
@@ -0,0 +1,54 @@
+package migrate
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// javaVersionRegex matches the Java versions of build files once unquoted: 8, 1.8, 17.0.2,
+// VERSION_1_8 and JavaVersion.VERSION_17
+var javaVersionRegex = regexp.MustCompile(`^(JavaVersion\.)?(VERSION_)?(\d+)(?:[._](\d+))?(?:[._]\d+)*$`)
+
+// parseJavaVersion parses a Java version in any format build files use, such as 8, 1.8,
+// "1.8", 17.0.2 or JavaVersion.VERSION_1_8. Versions 1.x before Java 9 parse as x.
+func parseJavaVersion(value string) (int, bool) {
+	match := javaVersionRegex.FindStringSubmatch(unquote(value))
+	if match == nil {
+		return 0, false
+	}
+
+	version, err := strconv.Atoi(match[3])
+	if err == nil && version == 1 && match[4] != "" {
+		version, err = strconv.Atoi(match[4])
+	}
+	if err != nil || version < 1 {
+		return 0, false
+	}
+	return version, true
+}
+
+// formatJavaVersion writes version in the format of previous, a version parseJavaVersion
+// accepts: quotes and JavaVersion constants are kept, and so is the 1.x form up to Java 8
+func formatJavaVersion(version int, previous string) string {
+	value := unquote(previous)
+	quote := previous[:(len(previous)-len(value))/2]
+
+	formatted := strconv.Itoa(version)
+	match := javaVersionRegex.FindStringSubmatch(value)
+	if match != nil && match[3] == "1" && match[4] != "" && version <= 8 {
+		formatted = "1." + formatted
+	}
+	if match != nil && match[2] != "" {
+		formatted = match[1] + match[2] + strings.ReplaceAll(formatted, ".", "_")
+	}
+	return quote + formatted + quote
+}
+
+// unquote removes the single or double quotes around value
+func unquote(value string) string {
+	if len(value) >= 2 && (value[0] == '"' || value[0] == '\'') && value[len(value)-1] == value[0] {
+		return value[1 : len(value)-1]
+	}
+	return value
+}
@@ -0,0 +1,394 @@
+package migrate
+
+import (
+	"strconv"
+	"strings"
+
+	"rewrite-migrate-java/pkg/maven"
+	"rewrite-migrate-java/pkg/recipe"
+)
+
+// UseMavenCompilerPluginReleaseConfiguration replaces the source and target of the
+// maven-compiler-plugin with release
+type UseMavenCompilerPluginReleaseConfiguration struct {
+	*recipe.BaseRecipe
+	ReleaseVersion int
+}
+
+// NewUseMavenCompilerPluginReleaseConfiguration creates a new UseMavenCompilerPluginReleaseConfiguration recipe
+func NewUseMavenCompilerPluginReleaseConfiguration(releaseVersion int) *UseMavenCompilerPluginReleaseConfiguration {
+	return &UseMavenCompilerPluginReleaseConfiguration{
+		BaseRecipe: &recipe.BaseRecipe{
+			Name:        "org.openrewrite.java.migrate.maven.UseMavenCompilerPluginReleaseConfiguration",
+			DisplayName: "Use Maven compiler plugin release configuration",
+			Description: "Replaces any explicit `source` or `target` configuration (if present) on the " +
+				"`maven-compiler-plugin` with `release`, and updates the `release` value if needed. " +
+				"Will not downgrade the Java version if the current version is higher.",
+		},
+		ReleaseVersion: releaseVersion,
+	}
+}
+
+func (u *UseMavenCompilerPluginReleaseConfiguration) GetVisitor() recipe.TreeVisitor {
+	return &mavenReleaseVisitor{update: func(r *releaseRewrite) {
+		useCompilerPluginRelease(r, u.ReleaseVersion)
+	}}
+}
+
+// UpdateMavenProjectPropertyJavaVersion updates the Java version properties of a Maven project
+type UpdateMavenProjectPropertyJavaVersion struct {
+	*recipe.BaseRecipe
+	Version int
+}
+
+// NewUpdateMavenProjectPropertyJavaVersion creates a new UpdateMavenProjectPropertyJavaVersion recipe
+func NewUpdateMavenProjectPropertyJavaVersion(version int) *UpdateMavenProjectPropertyJavaVersion {
+	return &UpdateMavenProjectPropertyJavaVersion{
+		BaseRecipe: &recipe.BaseRecipe{
+			Name:        "org.openrewrite.java.migrate.maven.UpdateMavenProjectPropertyJavaVersion",
+			DisplayName: "Update Maven Java project properties",
+			Description: "The Java version is determined by several project properties, including " +
+				"`java.version`, `jdk.version`, `javaVersion`, `jdkVersion`, `maven.compiler.source`, " +
+				"`maven.compiler.target`, `maven.compiler.release` and `release.version`. " +
+				"If none of these properties are in use and the maven compiler plugin is not otherwise " +
+				"configured, adds the `maven.compiler.release` property.",
+		},
+		Version: version,
+	}
+}
+
+func (u *UpdateMavenProjectPropertyJavaVersion) GetVisitor() recipe.TreeVisitor {
+	return &mavenReleaseVisitor{update: func(r *releaseRewrite) {
+		updateJavaVersionProperties(r, u.Version)
+	}}
+}
+
+// UseMavenCompilerReleaseProperty replaces the maven.compiler.source and
+// maven.compiler.target properties with maven.compiler.release
+type UseMavenCompilerReleaseProperty struct {
+	*recipe.BaseRecipe
+}
+
+// NewUseMavenCompilerReleaseProperty creates a new UseMavenCompilerReleaseProperty recipe
+func NewUseMavenCompilerReleaseProperty() *UseMavenCompilerReleaseProperty {
+	return &UseMavenCompilerReleaseProperty{
+		BaseRecipe: &recipe.BaseRecipe{
+			Name:        "org.openrewrite.java.migrate.maven.UseMavenCompilerReleaseProperty",
+			DisplayName: "Use the `maven.compiler.release` property",
+			Description: "Replaces the `maven.compiler.source` and `maven.compiler.target` properties with " +
+				"`maven.compiler.release`. Builds targeting Java 8 or older are left alone, since `release` " +
+				"requires JDK 9, and so are properties other settings of the reactor refer to or override.",
+		},
+	}
+}
+
+func (u *UseMavenCompilerReleaseProperty) GetVisitor() recipe.TreeVisitor {
+	return &mavenReleaseVisitor{update: useReleaseProperty}
+}
+
+// mavenReleaseVisitor parses POMs and hands them to update
+type mavenReleaseVisitor struct {
+	update func(r *releaseRewrite)
+}
+
+// releaseRewrite is the state of a release rewrite of one POM
+type releaseRewrite struct {
+	name    string
+	pom     *maven.POM
+	reactor *maven.Reactor
+}
+
+func (v *mavenReleaseVisitor) Visit(node recipe.SourceFile, ctx *recipe.ExecutionContext) (recipe.SourceFile, error) {
+	if !strings.HasSuffix(node.GetPath(), "pom.xml") {
+		return node, nil
+	}
+
+	content := node.GetContent()
+	pom, err := maven.Parse(content)
+	if err != nil {
+		ctx.Logger().Warn("Not rewriting a POM that cannot be parsed", "path", node.GetPath(), "error", err)
+		return node, nil
+	}
+
+	v.update(&releaseRewrite{name: node.GetPath(), pom: pom, reactor: mavenReactor(ctx, node.GetPath(), pom)})
+
+	if updatedContent := pom.String(); updatedContent != content {
+		return node.WithContent(updatedContent), nil
+	}
+	return node, nil
+}
+
+// useCompilerPluginRelease replaces source and target with release in every configuration of
+// the maven-compiler-plugin, and raises an older release to version. Configurations that
+// target a newer Java version, or one that cannot be resolved, are left alone.
+func useCompilerPluginRelease(r *releaseRewrite, version int) {
+	for _, model := range r.pom.Models() {
+		for _, plugin := range model.Plugins {
+			if !isCompilerPlugin(plugin) {
+				continue
+			}
+			for _, configuration := range plugin.Configurations() {
+				source, target, release := configuration.Child("source"), configuration.Child("target"), configuration.Child("release")
+				current, ok := r.currentVersion(model, source, target, release)
+				if !ok || current > version {
+					continue
+				}
+
+				if release != nil {
+					if current < version {
+						r.pom.SetText(release, r.releaseValue(model, version, release.Text()))
+					}
+					for _, element := range []*maven.Element{source, target} {
+						if element != nil {
+							r.pom.Remove(element)
+						}
+					}
+					continue
+				}
+
+				first, second := source, target
+				if first == nil || (second != nil && precedes(second, first)) {
+					first, second = second, first
+				}
+				value := ""
+				if second == nil || first.Text() == second.Text() {
+					value = first.Text()
+				}
+				r.pom.ReplaceElement(first, "release", r.releaseValue(model, version, value))
+				if second != nil {
+					r.pom.Remove(second)
+				}
+			}
+		}
+	}
+}
+
+// currentVersion returns the newest Java version the source, target and release elements,
+// any of which may be nil, resolve to. It is false when none is set or one cannot be resolved.
+func (r *releaseRewrite) currentVersion(model maven.Model, elements ...*maven.Element) (int, bool) {
+	current, found := 0, false
+	for _, element := range elements {
+		if element == nil {
+			continue
+		}
+		version, ok := parseJavaVersion(r.resolve(model, element.Text()))
+		if !ok {
+			return 0, false
+		}
+		current, found = max(current, version), true
+	}
+	return current, found
+}
+
+// releaseValue returns the value release is set to for version: the current value when it
+// already resolves to version, else ${java.version} when that property does, else version
+func (r *releaseRewrite) releaseValue(model maven.Model, version int, current string) string {
+	for _, value := range []string{current, "${java.version}"} {
+		if resolved, ok := parseJavaVersion(r.resolve(model, value)); ok && resolved == version {
+			return value
+		}
+	}
+	return strconv.Itoa(version)
+}
+
+// javaVersionPropertyNames are the properties UpdateMavenProjectPropertyJavaVersion updates
+var javaVersionPropertyNames = []string{
+	"java.version",
+	"jdk.version",
+	"javaVersion",
+	"jdkVersion",
+	"maven.compiler.source",
+	"maven.compiler.target",
+	"maven.compiler.release",
+	"release.version",
+}
+
+// updateJavaVersionProperties raises the Java version properties of the project and of each
+// profile that are older than version. Properties set by a placeholder are left alone. When the project declares or inherits none of them and
+// does not configure the compiler plugin, maven.compiler.release is added to its properties.
+func updateJavaVersionProperties(r *releaseRewrite, version int) {
+	inUse := false
+	for _, model := range r.pom.Models() {
+		for _, name := range javaVersionPropertyNames {
+			property, ok := model.Property(name)
+			if !ok {
+				continue
+			}
+			inUse = true
+			if current, ok := parseJavaVersion(property.Value); ok && current < version {
+				r.pom.SetText(property.Element, formatJavaVersion(version, property.Value))
+			}
+		}
+	}
+	if inUse {
+		return
+	}
+
+	for _, name := range javaVersionPropertyNames {
+		if _, ok := r.reactor.Resolve(r.name, name); ok {
+			return
+		}
+	}
+	for _, model := range r.pom.Models() {
+		for _, plugin := range model.Plugins {
+			if !isCompilerPlugin(plugin) {
+				continue
+			}
+			for _, configuration := range plugin.Configurations() {
+				for _, parameter := range configuration.Children {
+					if compilerVersionParameters[parameter.Name] {
+						return
+					}
+				}
+			}
+		}
+	}
+	// The property is only added where a <properties> element can take it
+	if properties := r.pom.Root.Child("properties"); properties != nil && len(properties.Children) > 0 {
+		r.pom.AddChild(properties, "maven.compiler.release", strconv.Itoa(version))
+	}
+}
+
+// useReleaseProperty replaces the maven.compiler.source and target properties of the project
+// and of each profile with maven.compiler.release
+func useReleaseProperty(r *releaseRewrite) {
+	for _, model := range r.pom.Models() {
+		source, hasSource := model.Property("maven.compiler.source")
+		target, hasTarget := model.Property("maven.compiler.target")
+		if !hasSource && !hasTarget {
+			continue
+		}
+		if r.propertiesInUse("maven.compiler.source", "maven.compiler.target") {
+			continue
+		}
+
+		if _, ok := model.Property("maven.compiler.release"); ok {
+			// maven.compiler.release takes precedence, so source and target have no effect
+			for _, property := range []maven.Property{source, target} {
+				if property.Element != nil {
+					r.pom.Remove(property.Element)
+				}
+			}
+			continue
+		}
+		r.merge(model, source.Element, target.Element, "maven.compiler.release")
+	}
+}
+
+// merge replaces the source and target elements, either of which may be nil, with a single
+// element named release, when both agree on a Java version that release supports. A missing
+// element takes its value from its maven.compiler property, and the elements are only merged
+// when that value is the same.
+func (r *releaseRewrite) merge(model maven.Model, source, target *maven.Element, release string) {
+	switch {
+	case source == nil && target == nil:
+		return
+	case source != nil && target != nil:
+		if source.Text() != target.Text() {
+			return
+		}
+	case source != nil:
+		if r.resolve(model, source.Text()) != r.missing(model, "target") {
+			return
+		}
+	default:
+		if r.resolve(model, target.Text()) != r.missing(model, "source") {
+			return
+		}
+	}
+
+	first, second := source, target
+	if first == nil || (second != nil && precedes(second, first)) {
+		first, second = second, first
+	}
+	value := first.Text()
+	if version, ok := parseJavaVersion(r.resolve(model, value)); !ok || version < 9 {
+		return
+	}
+
+	r.pom.ReplaceElement(first, release, value)
+	if second != nil {
+		r.pom.Remove(second)
+	}
+}
+
+// missing returns the value of the source or target a model leaves out: that of the
+// maven.compiler property of the same name
+func (r *releaseRewrite) missing(model maven.Model, name string) string {
+	return r.resolve(model, "${maven.compiler."+name+"}")
+}
+
+// resolve returns value with a ${property} placeholder replaced by the value of the property,
+// as seen from model, or an empty string when the property is not declared
+func (r *releaseRewrite) resolve(model maven.Model, value string) string {
+	property, ok := maven.Placeholder(value)
+	if !ok {
+		return value
+	}
+	if declared, ok := model.Property(property); ok {
+		if property, ok = maven.Placeholder(declared.Value); !ok {
+			return declared.Value
+		}
+	}
+	declaration, ok := r.reactor.Resolve(r.name, property)
+	if !ok {
+		return ""
+	}
+	// The reactor may hold an older version of this POM
+	if declaration.Path == r.name {
+		if declared, ok := r.pom.Property(declaration.Property.Name); ok {
+			return declared.Value
+		}
+	}
+	return declaration.Property.Value
+}
+
+// propertiesInUse reports whether this POM or one inheriting from it refers to one of the
+// properties, overrides it, or sets the compiler plugin version parameters itself. Removing
+// the properties would then change the build.
+func (r *releaseRewrite) propertiesInUse(properties ...string) bool {
+	poms := []*maven.POM{r.pom}
+	for _, inheritor := range r.reactor.Inheritors(r.name) {
+		pom, _ := r.reactor.POM(inheritor)
+		poms = append(poms, pom)
+	}
+
+	for i, pom := range poms {
+		for _, property := range properties {
+			if pom.References(property) {
+				return true
+			}
+		}
+		for _, model := range pom.Models() {
+			for _, property := range properties {
+				if _, ok := model.Property(property); ok && i > 0 {
+					return true
+				}
+			}
+			for _, plugin := range model.Plugins {
+				if !isCompilerPlugin(plugin) {
+					continue
+				}
+				for _, configuration := range plugin.Configurations() {
+					if configuration.Child("source") != nil || configuration.Child("target") != nil {
+						return true
+					}
+				}
+			}
+		}
+	}
+	return false
+}
+
+// precedes reports whether a comes before its sibling b
+func precedes(a, b *maven.Element) bool {
+	for _, child := range a.Parent.Children {
+		switch child {
+		case a:
+			return true
+		case b:
+			return false
+		}
+	}
+	return false
+}
@@ -0,0 +1,360 @@
+package migrate
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"rewrite-migrate-java/pkg/maven"
+	"rewrite-migrate-java/pkg/recipe"
+)
+
+func visitPom(t *testing.T, r recipe.Recipe, content string) string {
+	t.Helper()
+	result, err := r.GetVisitor().Visit(&mockSourceFile{path: "pom.xml", content: content}, recipe.NewExecutionContext(context.Background()))
+	if err != nil {
+		t.Fatalf("Visit failed: %v", err)
+	}
+	return result.GetContent()
+}
+
+func TestUseMavenCompilerPluginReleaseConfiguration(t *testing.T) {
+	pomContent := `<project>
+  <properties>
+    <jdk>17</jdk>
+  </properties>
+  <build>
+    <plugins>
+      <plugin>
+        <artifactId>maven-compiler-plugin</artifactId>
+        <configuration>
+          <source>${jdk}</source>
+          <target>${jdk}</target>
+          <encoding>UTF-8</encoding>
+        </configuration>
+      </plugin>
+    </plugins>
+  </build>
+  <profiles>
+    <profile>
+      <id>legacy</id>
+      <build>
+        <plugins>
+          <plugin>
+            <artifactId>maven-compiler-plugin</artifactId>
+            <configuration>
+              <source>8</source>
+              <target>8</target>
+            </configuration>
+          </plugin>
+        </plugins>
+      </build>
+    </profile>
+  </profiles>
+</project>`
+
+	expected := `<project>
+  <properties>
+    <jdk>17</jdk>
+  </properties>
+  <build>
+    <plugins>
+      <plugin>
+        <artifactId>maven-compiler-plugin</artifactId>
+        <configuration>
+          <release>${jdk}</release>
+          <encoding>UTF-8</encoding>
+        </configuration>
+      </plugin>
+    </plugins>
+  </build>
+  <profiles>
+    <profile>
+      <id>legacy</id>
+      <build>
+        <plugins>
+          <plugin>
+            <artifactId>maven-compiler-plugin</artifactId>
+            <configuration>
+              <release>17</release>
+            </configuration>
+          </plugin>
+        </plugins>
+      </build>
+    </profile>
+  </profiles>
+</project>`
+
+	if got := visitPom(t, NewUseMavenCompilerPluginReleaseConfiguration(17), pomContent); got != expected {
+		t.Errorf("Expected:\n%s\n\nGot:\n%s", expected, got)
+	}
+}
+
+func TestUseMavenCompilerReleaseProperty(t *testing.T) {
+	pomContent := `<project>
+  <properties>
+    <maven.compiler.source>11</maven.compiler.source>
+    <maven.compiler.target>11</maven.compiler.target>
+    <project.build.sourceEncoding>UTF-8</project.build.sourceEncoding>
+  </properties>
+  <profiles>
+    <profile>
+      <id>legacy</id>
+      <properties>
+        <maven.compiler.source>1.8</maven.compiler.source>
+        <maven.compiler.target>1.8</maven.compiler.target>
+      </properties>
+    </profile>
+  </profiles>
+</project>`
+
+	expected := `<project>
+  <properties>
+    <maven.compiler.release>11</maven.compiler.release>
+    <project.build.sourceEncoding>UTF-8</project.build.sourceEncoding>
+  </properties>
+  <profiles>
+    <profile>
+      <id>legacy</id>
+      <properties>
+        <maven.compiler.source>1.8</maven.compiler.source>
+        <maven.compiler.target>1.8</maven.compiler.target>
+      </properties>
+    </profile>
+  </profiles>
+</project>`
+
+	if got := visitPom(t, NewUseMavenCompilerReleaseProperty(), pomContent); got != expected {
+		t.Errorf("Expected:\n%s\n\nGot:\n%s", expected, got)
+	}
+}
+
+func TestUseMavenCompilerReleasePropertyKeepsReferencedProperties(t *testing.T) {
+	pomContent := `<project>
+  <properties>
+    <maven.compiler.source>17</maven.compiler.source>
+    <maven.compiler.target>17</maven.compiler.target>
+  </properties>
+  <build>
+    <plugins>
+      <plugin>
+        <groupId>org.codehaus.mojo</groupId>
+        <artifactId>animal-sniffer-maven-plugin</artifactId>
+        <configuration>
+          <jdk>${maven.compiler.target}</jdk>
+        </configuration>
+      </plugin>
+    </plugins>
+  </build>
+</project>`
+
+	if got := visitPom(t, NewUseMavenCompilerReleaseProperty(), pomContent); got != pomContent {
+		t.Errorf("Expected referenced properties to be kept, got:\n%s", got)
+	}
+}
+
+func TestUseMavenCompilerPluginReleaseConfigurationUpdatesRelease(t *testing.T) {
+	pomContent := `<project>
+  <properties>
+    <java.version>17</java.version>
+  </properties>
+  <build>
+    <plugins>
+      <plugin>
+        <artifactId>maven-compiler-plugin</artifactId>
+        <configuration>
+          <release>11</release>
+          <source>11</source>
+        </configuration>
+      </plugin>
+    </plugins>
+  </build>
+</project>`
+
+	// release is preferably set to ${java.version}, and source has no effect next to it
+	expected := strings.Replace(pomContent, `<release>11</release>
+          <source>11</source>`, `<release>${java.version}</release>`, 1)
+	if got := visitPom(t, NewUseMavenCompilerPluginReleaseConfiguration(17), pomContent); got != expected {
+		t.Errorf("Expected:\n%s\n\nGot:\n%s", expected, got)
+	}
+}
+
+func TestUseMavenCompilerPluginReleaseConfigurationDoesNotDowngrade(t *testing.T) {
+	for _, configuration := range []string{
+		"<release>21</release>",
+		"<source>11</source>\n          <target>21</target>",
+		"<source>${unknown}</source>",
+	} {
+		pomContent := `<project>
+  <build>
+    <plugins>
+      <plugin>
+        <artifactId>maven-compiler-plugin</artifactId>
+        <configuration>
+          ` + configuration + `
+        </configuration>
+      </plugin>
+    </plugins>
+  </build>
+</project>`
+
+		if got := visitPom(t, NewUseMavenCompilerPluginReleaseConfiguration(17), pomContent); got != pomContent {
+			t.Errorf("Expected %s to be kept, got:\n%s", configuration, got)
+		}
+	}
+}
+
+func TestUpdateMavenProjectPropertyJavaVersion(t *testing.T) {
+	pomContent := `<project>
+  <properties>
+    <java.version>1.8</java.version>
+    <maven.compiler.source>${java.version}</maven.compiler.source>
+    <maven.compiler.target>${java.version}</maven.compiler.target>
+    <release.version>21</release.version>
+    <project.build.sourceEncoding>UTF-8</project.build.sourceEncoding>
+  </properties>
+  <profiles>
+    <profile>
+      <id>legacy</id>
+      <properties>
+        <jdkVersion>11</jdkVersion>
+      </properties>
+    </profile>
+  </profiles>
+</project>`
+
+	expected := strings.NewReplacer(
+		"<java.version>1.8</java.version>", "<java.version>17</java.version>",
+		"<jdkVersion>11</jdkVersion>", "<jdkVersion>17</jdkVersion>",
+	).Replace(pomContent)
+	if got := visitPom(t, NewUpdateMavenProjectPropertyJavaVersion(17), pomContent); got != expected {
+		t.Errorf("Expected:\n%s\n\nGot:\n%s", expected, got)
+	}
+}
+
+func TestUpdateMavenProjectPropertyJavaVersionAddsRelease(t *testing.T) {
+	pomContent := `<project>
+  <properties>
+    <project.build.sourceEncoding>UTF-8</project.build.sourceEncoding>
+  </properties>
+</project>`
+
+	expected := strings.Replace(pomContent, "</project.build.sourceEncoding>",
+		"</project.build.sourceEncoding>\n    <maven.compiler.release>17</maven.compiler.release>", 1)
+	if got := visitPom(t, NewUpdateMavenProjectPropertyJavaVersion(17), pomContent); got != expected {
+		t.Errorf("Expected:\n%s\n\nGot:\n%s", expected, got)
+	}
+
+	// A configured compiler plugin sets the version already
+	configured := strings.Replace(pomContent, "</project>", `  <build>
+    <plugins>
+      <plugin>
+        <artifactId>maven-compiler-plugin</artifactId>
+        <configuration>
+          <release>11</release>
+        </configuration>
+      </plugin>
+    </plugins>
+  </build>
+</project>`, 1)
+	if got := visitPom(t, NewUpdateMavenProjectPropertyJavaVersion(17), configured); got != configured {
+		t.Errorf("Expected a POM configuring the compiler plugin to be kept, got:\n%s", got)
+	}
+}
+
+func TestUpdateMavenProjectPropertyJavaVersionInheritedProperty(t *testing.T) {
+	parent, err := maven.Parse(`<project>
+  <groupId>com.example</groupId>
+  <artifactId>parent</artifactId>
+  <version>1</version>
+  <properties>
+    <java.version>11</java.version>
+  </properties>
+</project>`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	child := `<project>
+  <parent>
+    <groupId>com.example</groupId>
+    <artifactId>parent</artifactId>
+    <version>1</version>
+  </parent>
+  <properties>
+    <project.build.sourceEncoding>UTF-8</project.build.sourceEncoding>
+  </properties>
+</project>`
+	childPom, err := maven.Parse(child)
+	if err != nil {
+		t.Fatal(err)
+	}
+	reactor := maven.NewReactor()
+	reactor.LocalRepository = ""
+	reactor.Add("pom.xml", parent)
+	reactor.Add("child/pom.xml", childPom)
+	ctx := recipe.NewExecutionContext(context.Background())
+	maven.SetReactor(ctx, reactor)
+
+	// The parent declares the version, so the child gets no property of its own
+	result, err := NewUpdateMavenProjectPropertyJavaVersion(17).GetVisitor().Visit(&mockSourceFile{path: "child/pom.xml", content: child}, ctx)
+	if err != nil {
+		t.Fatalf("Visit failed: %v", err)
+	}
+	if got := result.GetContent(); got != child {
+		t.Errorf("Expected the child to be kept, got:\n%s", got)
+	}
+}
+
+func TestUseMavenCompilerReleasePropertyWithSourceOnly(t *testing.T) {
+	child := `<project>
+  <parent>
+    <groupId>com.example</groupId>
+    <artifactId>parent</artifactId>
+    <version>1</version>
+  </parent>
+  <properties>
+    <maven.compiler.source>17</maven.compiler.source>
+  </properties>
+</project>`
+
+	if got := visitPom(t, NewUseMavenCompilerReleaseProperty(), child); got != child {
+		t.Errorf("Expected a source property without a known target to be kept, got:\n%s", got)
+	}
+
+	// The missing target resolves through the reactor to the parent
+	for target, expected := range map[string]string{
+		"11": child,
+		"17": strings.Replace(child, "<maven.compiler.source>17</maven.compiler.source>",
+			"<maven.compiler.release>17</maven.compiler.release>", 1),
+	} {
+		parent, err := maven.Parse(`<project>
+  <groupId>com.example</groupId>
+  <artifactId>parent</artifactId>
+  <version>1</version>
+  <properties>
+    <maven.compiler.target>` + target + `</maven.compiler.target>
+  </properties>
+</project>`)
+		if err != nil {
+			t.Fatal(err)
+		}
+		childPom, err := maven.Parse(child)
+		if err != nil {
+			t.Fatal(err)
+		}
+		reactor := maven.NewReactor()
+		reactor.LocalRepository = ""
+		reactor.Add("pom.xml", parent)
+		reactor.Add("child/pom.xml", childPom)
+		ctx := recipe.NewExecutionContext(context.Background())
+		maven.SetReactor(ctx, reactor)
+
+		result, err := NewUseMavenCompilerReleaseProperty().GetVisitor().Visit(&mockSourceFile{path: "child/pom.xml", content: child}, ctx)
+		if err != nil {
+			t.Fatalf("Visit failed: %v", err)
+		}
+		if got := result.GetContent(); got != expected {
+			t.Errorf("With target %s in the parent, expected:\n%s\n\nGot:\n%s", target, expected, got)
+		}
+	}
+}
@@ -0,0 +1,160 @@
+package migrate
+
+import (
+	"fmt"
+	"strconv"
+
+	"rewrite-migrate-java/pkg/recipe"
+)
+
+// NewRegistry creates a registry of the Go recipes keyed by their upstream OpenRewrite names
+func NewRegistry() *recipe.Registry {
+	registry := recipe.NewRegistry()
+	for _, d := range descriptors() {
+		// Recipes without required options describe themselves
+		if d.DisplayName == "" {
+			if r, err := d.New(nil); err == nil {
+				d.DisplayName = r.GetDisplayName()
+				d.Description = r.GetDescription()
+			}
+		}
+		if err := registry.Register(d); err != nil {
+			panic(err)
+		}
+	}
+	return registry
+}
+
+func descriptors() []recipe.Descriptor {
+	return []recipe.Descriptor{
+		{
+			Name:        "org.openrewrite.java.migrate.UpgradeJavaVersion",
+			DisplayName: "Upgrade Java version",
+			Description: "Upgrade build plugin configuration to use the specified Java version. " +
+				"Will not downgrade if the version is newer than the specified version.",
+			Options: []recipe.OptionDescriptor{
+				{Name: "version", Description: "The Java version to upgrade to", Required: true},
+			},
+			New: func(options map[string]string) (recipe.Recipe, error) {
+				version, err := parseVersionOption(options["version"])
+				if err != nil {
+					return nil, err
+				}
+				return NewUpgradeJavaVersion(version), nil
+			},
+		},
+		{
+			Name: "org.openrewrite.java.migrate.UseJavaUtilBase64",
+			Options: []recipe.OptionDescriptor{
+				{Name: "sunPackage", Description: "The package name of the sun.misc Base64 classes"},
+				{Name: "useMimeCoder", Description: "Use the MIME encoder and decoder"},
+			},
+			New: func(options map[string]string) (recipe.Recipe, error) {
+				useMimeCoder := false
+				if value, ok := options["useMimeCoder"]; ok {
+					var err error
+					useMimeCoder, err = strconv.ParseBool(value)
+					if err != nil {
+						return nil, fmt.Errorf("invalid useMimeCoder option %q: %w", value, err)
+					}
+				}
+				return NewUseJavaUtilBase64(options["sunPackage"], useMimeCoder), nil
+			},
+		},
+		{
+			Name:        "org.openrewrite.java.migrate.maven.UseMavenCompilerPluginReleaseConfiguration",
+			DisplayName: "Use Maven compiler plugin release configuration",
+			Description: "Replaces any explicit `source` or `target` configuration (if present) on the " +
+				"`maven-compiler-plugin` with `release`, and updates the `release` value if needed. " +
+				"Will not downgrade the Java version if the current version is higher.",
+			Options: []recipe.OptionDescriptor{
+				{Name: "releaseVersion", Description: "The new value for the release configuration", Required: true},
+			},
+			New: func(options map[string]string) (recipe.Recipe, error) {
+				releaseVersion, err := parseVersionOption(options["releaseVersion"])
+				if err != nil {
+					return nil, err
+				}
+				return NewUseMavenCompilerPluginReleaseConfiguration(releaseVersion), nil
+			},
+		},
+		{
+			Name:        "org.openrewrite.java.migrate.maven.UpdateMavenProjectPropertyJavaVersion",
+			DisplayName: "Update Maven Java project properties",
+			Description: "Updates the Java version properties of a Maven project, or adds the " +
+				"`maven.compiler.release` property when none is in use.",
+			Options: []recipe.OptionDescriptor{
+				{Name: "version", Description: "The Java version to upgrade to", Required: true},
+			},
+			New: func(options map[string]string) (recipe.Recipe, error) {
+				version, err := parseVersionOption(options["version"])
+				if err != nil {
+					return nil, err
+				}
+				return NewUpdateMavenProjectPropertyJavaVersion(version), nil
+			},
+		},
+		{
+			Name: "org.openrewrite.java.migrate.maven.UseMavenCompilerReleaseProperty",
+			New: func(map[string]string) (recipe.Recipe, error) {
+				return NewUseMavenCompilerReleaseProperty(), nil
+			},
+		},
+		{
+			Name: "org.openrewrite.java.migrate.UpgradeToJava6",
+			New: func(map[string]string) (recipe.Recipe, error) {
+				return NewUpgradeToJava6(), nil
+			},
+		},
+		{
+			Name: "org.openrewrite.java.migrate.UpgradeToJava7",
+			New: func(map[string]string) (recipe.Recipe, error) {
+				return NewUpgradeToJava7(), nil
+			},
+		},
+		{
+			Name: "org.openrewrite.java.migrate.UpgradeToJava8",
+			New: func(map[string]string) (recipe.Recipe, error) {
+				return NewUpgradeToJava8(), nil
+			},
+		},
+		{
+			Name: "org.openrewrite.java.migrate.Java8toJava11",
+			New: func(map[string]string) (recipe.Recipe, error) {
+				return NewJava8ToJava11(), nil
+			},
+		},
+		{
+			Name: "org.openrewrite.java.migrate.UpgradeToJava17",
+			New: func(map[string]string) (recipe.Recipe, error) {
+				return NewUpgradeToJava17(), nil
+			},
+		},
+		{
+			Name: "org.openrewrite.java.migrate.UpgradeToJava21",
+			New: func(map[string]string) (recipe.Recipe, error) {
+				return NewUpgradeToJava21(), nil
+			},
+		},
+		{
+			Name: "org.openrewrite.java.migrate.UpgradeToJava25",
+			New: func(map[string]string) (recipe.Recipe, error) {
+				return NewUpgradeToJava25(), nil
+			},
+		},
+		{
+			Name: "org.openrewrite.java.migrate.jakarta.JavaxMigrationToJakarta",
+			New: func(map[string]string) (recipe.Recipe, error) {
+				return NewJavaEEToJakartaEE(), nil
+			},
+		},
+	}
+}
+
+func parseVersionOption(value string) (int, error) {
+	version, err := strconv.Atoi(value)
+	if err != nil {
+		return 0, fmt.Errorf("invalid version option %q: %w", value, err)
+	}
+	return version, nil
+}
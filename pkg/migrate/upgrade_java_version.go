@@ -6,6 +6,7 @@ import (
 	"strings"
 	"time"
 
+	"rewrite-migrate-java/pkg/maven"
 	"rewrite-migrate-java/pkg/recipe"
 )
 
@@ -19,6 +20,7 @@ type UpgradeJavaVersion struct {
 func NewUpgradeJavaVersion(version int) *UpgradeJavaVersion {
 	return &UpgradeJavaVersion{
 		BaseRecipe: &recipe.BaseRecipe{
+			Name:        "org.openrewrite.java.migrate.UpgradeJavaVersion",
 			DisplayName: "Upgrade Java version",
 			Description: fmt.Sprintf("Upgrade build plugin configuration to use Java %d. "+
 				"This recipe changes java.toolchain.languageVersion in build.gradle(.kts) of gradle projects, "+
@@ -55,7 +57,7 @@ func (v *UpgradeJavaVersionVisitor) Visit(node recipe.SourceFile, ctx *recipe.Ex
 
 	// Handle different build file types
 	if strings.HasSuffix(path, "pom.xml") {
-		return v.updateMavenPom(node, content)
+		return v.updateMavenPom(node, content, ctx)
 	}
 
 	if strings.HasSuffix(path, "build.gradle") || strings.HasSuffix(path, "build.gradle.kts") {
@@ -66,77 +68,156 @@ func (v *UpgradeJavaVersionVisitor) Visit(node recipe.SourceFile, ctx *recipe.Ex
 	return node, nil
 }
 
-func (v *UpgradeJavaVersionVisitor) updateMavenPom(node recipe.SourceFile, content string) (recipe.SourceFile, error) {
-	// Update Maven compiler plugin configuration
-	patterns := []struct {
-		regex       *regexp.Regexp
-		replacement string
-	}{
-		{
-			regex:       regexp.MustCompile(`(<maven\.compiler\.source>)\d+(<\/maven\.compiler\.source>)`),
-			replacement: fmt.Sprintf("${1}%d${2}", v.targetVersion),
-		},
-		{
-			regex:       regexp.MustCompile(`(<maven\.compiler\.target>)\d+(<\/maven\.compiler\.target>)`),
-			replacement: fmt.Sprintf("${1}%d${2}", v.targetVersion),
-		},
-		{
-			regex:       regexp.MustCompile(`(<source>)\d+(<\/source>)`),
-			replacement: fmt.Sprintf("${1}%d${2}", v.targetVersion),
-		},
-		{
-			regex:       regexp.MustCompile(`(<target>)\d+(<\/target>)`),
-			replacement: fmt.Sprintf("${1}%d${2}", v.targetVersion),
-		},
-		{
-			regex:       regexp.MustCompile(`(<release>)\d+(<\/release>)`),
-			replacement: fmt.Sprintf("${1}%d${2}", v.targetVersion),
-		},
+// javaVersionProperties are the properties that hold the Java version of a Maven build
+var javaVersionProperties = map[string]bool{
+	"java.version":               true,
+	"maven.compiler.source":      true,
+	"maven.compiler.target":      true,
+	"maven.compiler.release":     true,
+	"maven.compiler.testSource":  true,
+	"maven.compiler.testTarget":  true,
+	"maven.compiler.testRelease": true,
+}
+
+// compilerVersionParameters are the maven-compiler-plugin parameters that hold a Java version
+var compilerVersionParameters = map[string]bool{
+	"source":      true,
+	"target":      true,
+	"release":     true,
+	"testSource":  true,
+	"testTarget":  true,
+	"testRelease": true,
+}
+
+// updateMavenPom sets the Java version properties and the maven-compiler-plugin configuration
+// of the project and of each profile. Nothing else in the POM is touched. Settings that use a
+// ${property} are updated where the property is declared: in this POM for its own settings
+// and for those of the reactor POMs that inherit from it.
+func (v *UpgradeJavaVersionVisitor) updateMavenPom(node recipe.SourceFile, content string, ctx *recipe.ExecutionContext) (recipe.SourceFile, error) {
+	pom, err := maven.Parse(content)
+	if err != nil {
+		ctx.Logger().Warn("Not upgrading a POM that cannot be parsed", "path", node.GetPath(), "error", err)
+		return node, nil
 	}
 
-	updatedContent := content
-	for _, pattern := range patterns {
-		updatedContent = pattern.regex.ReplaceAllString(updatedContent, pattern.replacement)
+	name := node.GetPath()
+	reactor := mavenReactor(ctx, name, pom)
+
+	update := func(element *maven.Element) {
+		if current, ok := parseJavaVersion(element.Text()); ok && current < v.targetVersion {
+			pom.SetText(element, formatJavaVersion(v.targetVersion, element.Text()))
+		}
+	}
+	// updateDeclaration updates the property a ${property} setting of model, in the POM at
+	// from, refers to when this POM declares it. Properties of the model itself, which may be
+	// a profile, take precedence over those of the project and its parents.
+	updateDeclaration := func(from string, model maven.Model, property string) {
+		if declared, ok := model.Property(property); ok {
+			next, isPlaceholder := maven.Placeholder(declared.Value)
+			if !isPlaceholder {
+				if from == name {
+					update(declared.Element)
+				}
+				return
+			}
+			property = next
+		}
+		if declaration, ok := reactor.Resolve(from, property); ok && declaration.Path == name {
+			if declared, ok := pom.Property(declaration.Property.Name); ok {
+				update(declared.Element)
+			}
+		}
 	}
 
-	if updatedContent != content {
+	for _, model := range pom.Models() {
+		for _, setting := range javaVersionSettings(model) {
+			if property, ok := maven.Placeholder(setting.Text()); ok {
+				updateDeclaration(name, model, property)
+			} else {
+				update(setting)
+			}
+		}
+	}
+
+	for _, inheritor := range reactor.Inheritors(name) {
+		inheritorPOM, _ := reactor.POM(inheritor)
+		for _, model := range inheritorPOM.Models() {
+			for _, setting := range javaVersionSettings(model) {
+				if property, ok := maven.Placeholder(setting.Text()); ok {
+					updateDeclaration(inheritor, model, property)
+				}
+			}
+		}
+	}
+
+	if updatedContent := pom.String(); updatedContent != content {
 		return node.WithContent(updatedContent), nil
 	}
 
 	return node, nil
 }
 
-func (v *UpgradeJavaVersionVisitor) updateGradleBuild(node recipe.SourceFile, content string) (recipe.SourceFile, error) {
-	// Update Gradle build configuration
-	patterns := []struct {
-		regex       *regexp.Regexp
-		replacement string
-	}{
-		{
-			regex:       regexp.MustCompile(`(sourceCompatibility\s*=\s*JavaVersion\.VERSION_)\d+`),
-			replacement: fmt.Sprintf("${1}%d", v.targetVersion),
-		},
-		{
-			regex:       regexp.MustCompile(`(targetCompatibility\s*=\s*JavaVersion\.VERSION_)\d+`),
-			replacement: fmt.Sprintf("${1}%d", v.targetVersion),
-		},
-		{
-			regex:       regexp.MustCompile(`(sourceCompatibility\s*=\s*)\d+`),
-			replacement: fmt.Sprintf("${1}%d", v.targetVersion),
-		},
-		{
-			regex:       regexp.MustCompile(`(targetCompatibility\s*=\s*)\d+`),
-			replacement: fmt.Sprintf("${1}%d", v.targetVersion),
-		},
-		{
-			regex:       regexp.MustCompile(`(languageVersion\s*=\s*JavaLanguageVersion\.of\()\d+(\))`),
-			replacement: fmt.Sprintf("${1}%d${2}", v.targetVersion),
-		},
+// mavenReactor returns the reactor of the run when it holds the POM at name. Otherwise only
+// the POM itself and its parents in the local repository are known.
+func mavenReactor(ctx *recipe.ExecutionContext, name string, pom *maven.POM) *maven.Reactor {
+	if reactor := maven.ReactorFrom(ctx); reactor != nil {
+		if _, ok := reactor.POM(name); ok {
+			return reactor
+		}
+	}
+	reactor := maven.NewReactor()
+	reactor.Add(name, pom)
+	return reactor
+}
+
+// isCompilerPlugin reports whether plugin is the maven-compiler-plugin
+func isCompilerPlugin(plugin maven.Plugin) bool {
+	return plugin.GroupID == "org.apache.maven.plugins" && plugin.ArtifactID == "maven-compiler-plugin"
+}
+
+// javaVersionSettings returns the elements of model that set a Java version: the Java version
+// properties and the version parameters of the maven-compiler-plugin
+func javaVersionSettings(model maven.Model) []*maven.Element {
+	var settings []*maven.Element
+	for _, property := range model.Properties {
+		if javaVersionProperties[property.Name] {
+			settings = append(settings, property.Element)
+		}
+	}
+	for _, plugin := range model.Plugins {
+		if !isCompilerPlugin(plugin) {
+			continue
+		}
+		for _, configuration := range plugin.Configurations() {
+			for _, parameter := range configuration.Children {
+				if compilerVersionParameters[parameter.Name] {
+					settings = append(settings, parameter)
+				}
+			}
+		}
 	}
+	return settings
+}
+
+// gradleJavaVersionRegexes match the Java version settings of Gradle builds. The second
+// group holds the version.
+var gradleJavaVersionRegexes = []*regexp.Regexp{
+	regexp.MustCompile(`((?:source|target)Compatibility\s*=\s*)(JavaVersion\.VERSION_[0-9_]+|"[0-9.]+"|'[0-9.]+'|[0-9.]+)()`),
+	regexp.MustCompile(`(JavaLanguageVersion\.of\(\s*)(\d+|"\d+"|'\d+')(\s*\))`),
+}
 
+// updateGradleBuild sets the source and target compatibility and the toolchain language
+// version of a Gradle build, unless they are newer already
+func (v *UpgradeJavaVersionVisitor) updateGradleBuild(node recipe.SourceFile, content string) (recipe.SourceFile, error) {
 	updatedContent := content
-	for _, pattern := range patterns {
-		updatedContent = pattern.regex.ReplaceAllString(updatedContent, pattern.replacement)
+	for _, re := range gradleJavaVersionRegexes {
+		updatedContent = re.ReplaceAllStringFunc(updatedContent, func(match string) string {
+			groups := re.FindStringSubmatch(match)
+			if current, ok := parseJavaVersion(groups[2]); !ok || current >= v.targetVersion {
+				return match
+			}
+			return groups[1] + formatJavaVersion(v.targetVersion, groups[2]) + groups[3]
+		})
 	}
 
 	if updatedContent != content {
@@ -25,6 +25,11 @@ func TestUpgradeJavaVersionMaven(t *testing.T) {
     </properties>
 </project>`
 
+	ctx := &recipe.ExecutionContext{
+		Context:    context.Background(),
+		Properties: make(map[string]interface{}),
+	}
+
 	recipe := NewUpgradeJavaVersion(17)
 	visitor := recipe.GetVisitor()
 
@@ -33,11 +38,6 @@ func TestUpgradeJavaVersionMaven(t *testing.T) {
 		content: pomContent,
 	}
 
-	ctx := &recipe.ExecutionContext{
-		Context:    context.Background(),
-		Properties: make(map[string]interface{}),
-	}
-
 	result, err := visitor.Visit(sourceFile, ctx)
 	if err != nil {
 		t.Fatalf("Visit failed: %v", err)
@@ -48,6 +48,72 @@ func TestUpgradeJavaVersionMaven(t *testing.T) {
 	}
 }
 
+func TestUpgradeJavaVersionMavenOnlyEditsCompilerSettings(t *testing.T) {
+	pomContent := `<project>
+  <build>
+    <plugins>
+      <plugin>
+        <artifactId>maven-compiler-plugin</artifactId>
+        <executions>
+          <execution>
+            <configuration>
+              <testRelease>8</testRelease>
+            </configuration>
+          </execution>
+        </executions>
+      </plugin>
+      <plugin>
+        <artifactId>maven-antrun-plugin</artifactId>
+        <configuration>
+          <target>8</target>
+        </configuration>
+      </plugin>
+      <plugin>
+        <artifactId>maven-resources-plugin</artifactId>
+        <configuration>
+          <source>8</source>
+        </configuration>
+      </plugin>
+    </plugins>
+  </build>
+  <profiles>
+    <profile>
+      <id>legacy</id>
+      <properties>
+        <java.version>8</java.version>
+        <other.version>8</other.version>
+      </properties>
+    </profile>
+  </profiles>
+</project>`
+
+	expected := strings.NewReplacer(
+		"<testRelease>8</testRelease>", "<testRelease>17</testRelease>",
+		"<java.version>8</java.version>", "<java.version>17</java.version>",
+	).Replace(pomContent)
+
+	sourceFile := &mockSourceFile{path: "pom.xml", content: pomContent}
+	result, err := NewUpgradeJavaVersion(17).GetVisitor().Visit(sourceFile, recipe.NewExecutionContext(context.Background()))
+	if err != nil {
+		t.Fatalf("Visit failed: %v", err)
+	}
+
+	if result.GetContent() != expected {
+		t.Errorf("Expected:\n%s\n\nGot:\n%s", expected, result.GetContent())
+	}
+}
+
+func TestUpgradeJavaVersionMavenSkipsUnparsablePom(t *testing.T) {
+	sourceFile := &mockSourceFile{path: "pom.xml", content: "<project><properties><java.version>8</java.version></project>"}
+	result, err := NewUpgradeJavaVersion(17).GetVisitor().Visit(sourceFile, recipe.NewExecutionContext(context.Background()))
+	if err != nil {
+		t.Fatalf("Visit failed: %v", err)
+	}
+	if result.GetContent() != sourceFile.content {
+		t.Errorf("Expected an unparsable POM to stay unchanged, got:\n%s", result.GetContent())
+	}
+}
+
 func TestUpgradeJavaVersionGradle(t *testing.T) {
 	gradleContent := `plugins {
     id 'java'
@@ -63,6 +129,11 @@ targetCompatibility = JavaVersion.VERSION_8`
 sourceCompatibility = JavaVersion.VERSION_17
 targetCompatibility = JavaVersion.VERSION_17`
 
+	ctx := &recipe.ExecutionContext{
+		Context:    context.Background(),
+		Properties: make(map[string]interface{}),
+	}
+
 	recipe := NewUpgradeJavaVersion(17)
 	visitor := recipe.GetVisitor()
 
@@ -71,11 +142,6 @@ targetCompatibility = JavaVersion.VERSION_17`
 		content: gradleContent,
 	}
 
-	ctx := &recipe.ExecutionContext{
-		Context:    context.Background(),
-		Properties: make(map[string]interface{}),
-	}
-
 	result, err := visitor.Visit(sourceFile, ctx)
 	if err != nil {
 		t.Fatalf("Visit failed: %v", err)
@@ -86,6 +152,77 @@ targetCompatibility = JavaVersion.VERSION_17`
 	}
 }
 
+func TestUpgradeJavaVersionDoesNotDowngrade(t *testing.T) {
+	sources := []*mockSourceFile{
+		{path: "pom.xml", content: "<project>\n  <properties>\n    <maven.compiler.release>21</maven.compiler.release>\n  </properties>\n</project>"},
+		{path: "build.gradle", content: "sourceCompatibility = JavaVersion.VERSION_21\njava.toolchain.languageVersion = JavaLanguageVersion.of(21)"},
+	}
+
+	for _, source := range sources {
+		result, err := NewUpgradeJavaVersion(17).GetVisitor().Visit(source, recipe.NewExecutionContext(context.Background()))
+		if err != nil {
+			t.Fatalf("Visit failed: %v", err)
+		}
+		if result.GetContent() != source.content {
+			t.Errorf("Expected %s to stay on Java 21, got:\n%s", source.path, result.GetContent())
+		}
+	}
+}
+
+func TestUpgradeJavaVersionLegacyFormats(t *testing.T) {
+	tests := []struct {
+		version  int
+		path     string
+		content  string
+		expected string
+	}{
+		{17, "pom.xml", "<project><properties><java.version>1.8</java.version></properties></project>",
+			"<project><properties><java.version>17</java.version></properties></project>"},
+		{8, "pom.xml", "<project><properties><maven.compiler.target>1.6</maven.compiler.target></properties></project>",
+			"<project><properties><maven.compiler.target>1.8</maven.compiler.target></properties></project>"},
+		{17, "build.gradle", "sourceCompatibility = JavaVersion.VERSION_1_8\ntargetCompatibility = '1.8'",
+			"sourceCompatibility = JavaVersion.VERSION_17\ntargetCompatibility = '17'"},
+		{11, "build.gradle.kts", "java {\n    sourceCompatibility = JavaVersion.VERSION_1_7\n    targetCompatibility = \"1.7\"\n}",
+			"java {\n    sourceCompatibility = JavaVersion.VERSION_11\n    targetCompatibility = \"11\"\n}"},
+		{8, "build.gradle", "sourceCompatibility = 1.7", "sourceCompatibility = 1.8"},
+	}
+
+	for _, test := range tests {
+		source := &mockSourceFile{path: test.path, content: test.content}
+		result, err := NewUpgradeJavaVersion(test.version).GetVisitor().Visit(source, recipe.NewExecutionContext(context.Background()))
+		if err != nil {
+			t.Fatalf("Visit failed: %v", err)
+		}
+		if result.GetContent() != test.expected {
+			t.Errorf("Upgrading %q to Java %d: expected %q, got %q", test.content, test.version, test.expected, result.GetContent())
+		}
+	}
+}
+
+func TestParseJavaVersion(t *testing.T) {
+	tests := map[string]int{
+		"8":                       8,
+		"1.8":                     8,
+		`"1.8"`:                   8,
+		"'11'":                    11,
+		"17.0.2":                  17,
+		"VERSION_1_8":             8,
+		"JavaVersion.VERSION_21":  21,
+		"JavaVersion.VERSION_1_5": 5,
+	}
+	for value, expected := range tests {
+		if version, ok := parseJavaVersion(value); !ok || version != expected {
+			t.Errorf("parseJavaVersion(%q) = %d, %v, expected %d", value, version, ok, expected)
+		}
+	}
+
+	for _, value := range []string{"", "${java.version}", "JavaVersion.VERSION_HIGHER", "latest"} {
+		if _, ok := parseJavaVersion(value); ok {
+			t.Errorf("Expected %q not to parse", value)
+		}
+	}
+}
+
 func TestUseJavaUtilBase64(t *testing.T) {
 	javaContent := `import sun.misc.BASE64Encoder;
 import sun.misc.BASE64Decoder;
@@ -102,6 +239,11 @@ public class Example {
     }
 }`
 
+	ctx := &recipe.ExecutionContext{
+		Context:    context.Background(),
+		Properties: make(map[string]interface{}),
+	}
+
 	recipe := NewUseJavaUtilBase64("sun.misc", false)
 	visitor := recipe.GetVisitor()
 
@@ -110,11 +252,6 @@ public class Example {
 		content: javaContent,
 	}
 
-	ctx := &recipe.ExecutionContext{
-		Context:    context.Background(),
-		Properties: make(map[string]interface{}),
-	}
-
 	result, err := visitor.Visit(sourceFile, ctx)
 	if err != nil {
 		t.Fatalf("Visit failed: %v", err)
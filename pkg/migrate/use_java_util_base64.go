@@ -22,6 +22,7 @@ func NewUseJavaUtilBase64(sunPackage string, useMimeCoder bool) *UseJavaUtilBase
 
 	return &UseJavaUtilBase64{
 		BaseRecipe: &recipe.BaseRecipe{
+			Name:        "org.openrewrite.java.migrate.UseJavaUtilBase64",
 			DisplayName: "Prefer `java.util.Base64` instead of `sun.misc`",
 			Description: "Prefer `java.util.Base64` instead of using `sun.misc` in Java 8 or higher. " +
 				"`sun.misc` is not exported by the Java module system and accessing this class will " +
@@ -59,7 +60,10 @@ func (v *UseJavaUtilBase64Visitor) Visit(node recipe.SourceFile, ctx *recipe.Exe
 
 	// Check if already using incompatible Base64
 	if v.alreadyUsingIncompatibleBase64(node) {
-		// Return with warning - manual intervention required
+		// Manual intervention is required where the file still uses the sun.misc classes
+		if strings.Contains(content, v.sunPackage+".BASE64") {
+			ctx.Logger().Warn("Not migrating a file that already uses another Base64 class", "path", node.GetPath())
+		}
 		return node, nil
 	}
 
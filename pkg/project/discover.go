@@ -0,0 +1,280 @@
+package project
+
+import (
+	"encoding/xml"
+	"fmt"
+	"io/fs"
+	"path"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+
+	"rewrite-migrate-java/pkg/textfile"
+)
+
+// BuildFileNames lists the build files recognized in each module directory
+var BuildFileNames = []string{
+	"pom.xml",
+	"build.gradle",
+	"build.gradle.kts",
+}
+
+// DefaultSourceDirs are scanned in every module in addition to declared source directories
+var DefaultSourceDirs = []string{
+	"src/main/java",
+	"src/test/java",
+}
+
+// Module is a single Maven or Gradle module of a project. Its directories and files are
+// slash-separated paths in the file system of the project.
+type Module struct {
+	// Path is the module directory relative to the project root, "." for the root module
+	Path string
+	// Dir is the module directory
+	Dir string
+	// BuildFiles are the paths of the module's build files
+	BuildFiles []string
+	// SourceDirs are the paths of the module's existing Java source directories
+	SourceDirs []string
+	// Outside lists the modules and source directories the build files declare outside the
+	// project root, such as ../shared or an absolute path, as written. They are not visited.
+	Outside []string
+	// BuildFileErrors holds an error for each build file that could not be parsed. The module
+	// is still visited, with the source directories that other build files and the defaults give.
+	BuildFileErrors []error
+}
+
+// DiscoverFS finds the root module of the project at the root of fsys and every module
+// reachable through Maven <modules> or Gradle settings includes. Each module is scanned for
+// defaultSourceDirs and any source directories declared in its build files. Modules and
+// source directories outside fsys are listed in Module.Outside, and build files that cannot
+// be parsed in Module.BuildFileErrors.
+func DiscoverFS(fsys fs.FS, defaultSourceDirs []string) ([]Module, error) {
+	seen := make(map[string]bool)
+	var modules []Module
+
+	queue := []string{"."}
+	for len(queue) > 0 {
+		dir := queue[0]
+		queue = queue[1:]
+		if seen[dir] {
+			continue
+		}
+		seen[dir] = true
+
+		module, children, err := inspectModule(fsys, dir, defaultSourceDirs)
+		if err != nil {
+			return nil, err
+		}
+		modules = append(modules, module)
+		queue = append(queue, children...)
+	}
+
+	sort.Slice(modules, func(i, j int) bool {
+		return modules[i].Path < modules[j].Path
+	})
+	return modules, nil
+}
+
+func inspectModule(fsys fs.FS, dir string, defaultSourceDirs []string) (Module, []string, error) {
+	module := Module{
+		Path: dir,
+		Dir:  dir,
+	}
+
+	for _, name := range BuildFileNames {
+		buildFile := path.Join(dir, name)
+		if _, err := fs.Stat(fsys, buildFile); err == nil {
+			module.BuildFiles = append(module.BuildFiles, buildFile)
+		}
+	}
+
+	sourceDirs := append([]string{}, defaultSourceDirs...)
+	var children []string
+
+	for _, buildFile := range module.BuildFiles {
+		content, err := fs.ReadFile(fsys, buildFile)
+		if err != nil {
+			return Module{}, nil, fmt.Errorf("failed to read build file %s: %w", buildFile, err)
+		}
+
+		if path.Base(buildFile) == "pom.xml" {
+			pom, err := parsePom(content)
+			if err != nil {
+				module.BuildFileErrors = append(module.BuildFileErrors, fmt.Errorf("failed to parse %s: %w", buildFile, err))
+				continue
+			}
+			sourceDirs = append(sourceDirs, pom.sourceDirs...)
+			for _, child := range pom.modules {
+				children = module.addChild(children, child)
+			}
+			continue
+		}
+
+		sourceDirs = append(sourceDirs, gradleSourceDirs(string(content))...)
+	}
+
+	for _, settings := range []string{"settings.gradle", "settings.gradle.kts"} {
+		content, err := fs.ReadFile(fsys, path.Join(dir, settings))
+		if err != nil {
+			continue
+		}
+		for _, child := range gradleIncludes(string(content)) {
+			children = module.addChild(children, child)
+		}
+	}
+
+	module.SourceDirs = module.existingSourceDirs(fsys, sourceDirs)
+	return module, children, nil
+}
+
+// addChild appends the directory of the declared child module to children, or records it in
+// Outside when it lies outside the project root
+func (m *Module) addChild(children []string, child string) []string {
+	dir, ok := m.resolve(child)
+	if !ok {
+		m.Outside = append(m.Outside, child)
+		return children
+	}
+	return append(children, dir)
+}
+
+// resolve returns the path of a directory declared relative to the module, or false when it
+// lies outside the project root
+func (m *Module) resolve(declared string) (string, bool) {
+	if filepath.IsAbs(declared) || path.IsAbs(filepath.ToSlash(declared)) {
+		return "", false
+	}
+	dir := path.Join(m.Dir, filepath.ToSlash(declared))
+	return dir, fs.ValidPath(dir)
+}
+
+// existingSourceDirs resolves dirs against the module directory, dropping missing,
+// duplicate and nested directories so that no file is visited twice
+func (m *Module) existingSourceDirs(fsys fs.FS, dirs []string) []string {
+	var resolved []string
+	for _, declared := range dirs {
+		declared = strings.TrimSpace(declared)
+		if declared == "" || strings.Contains(declared, "${") {
+			continue
+		}
+		dir, ok := m.resolve(declared)
+		if !ok {
+			m.Outside = append(m.Outside, declared)
+			continue
+		}
+		if info, err := fs.Stat(fsys, dir); err == nil && info.IsDir() {
+			resolved = append(resolved, dir)
+		}
+	}
+
+	sort.Strings(resolved)
+	var result []string
+	for _, dir := range resolved {
+		if len(result) > 0 {
+			last := result[len(result)-1]
+			if dir == last || last == "." || strings.HasPrefix(dir, last+"/") {
+				continue
+			}
+		}
+		result = append(result, dir)
+	}
+	return result
+}
+
+type pomFile struct {
+	Modules  []string `xml:"modules>module"`
+	Build    pomBuild `xml:"build"`
+	Profiles []struct {
+		Modules []string `xml:"modules>module"`
+		Build   pomBuild `xml:"build"`
+	} `xml:"profiles>profile"`
+}
+
+type pomBuild struct {
+	SourceDirectory     string `xml:"sourceDirectory"`
+	TestSourceDirectory string `xml:"testSourceDirectory"`
+	Plugins             []struct {
+		ArtifactID string `xml:"artifactId"`
+		Executions []struct {
+			Sources []string `xml:"configuration>sources>source"`
+		} `xml:"executions>execution"`
+	} `xml:"plugins>plugin"`
+}
+
+type parsedPom struct {
+	modules    []string
+	sourceDirs []string
+}
+
+// parsePom reads the modules and source directories of a POM in any encoding textfile decodes
+func parsePom(content []byte) (parsedPom, error) {
+	text, _ := textfile.Decode(content)
+	decoder := xml.NewDecoder(strings.NewReader(text))
+	decoder.CharsetReader = textfile.DecodedCharsetReader
+
+	var pom pomFile
+	if err := decoder.Decode(&pom); err != nil {
+		return parsedPom{}, err
+	}
+
+	result := parsedPom{modules: pom.Modules}
+	builds := []pomBuild{pom.Build}
+	for _, profile := range pom.Profiles {
+		result.modules = append(result.modules, profile.Modules...)
+		builds = append(builds, profile.Build)
+	}
+
+	for _, build := range builds {
+		result.sourceDirs = append(result.sourceDirs, build.SourceDirectory, build.TestSourceDirectory)
+		for _, plugin := range build.Plugins {
+			if plugin.ArtifactID != "build-helper-maven-plugin" {
+				continue
+			}
+			for _, execution := range plugin.Executions {
+				result.sourceDirs = append(result.sourceDirs, execution.Sources...)
+			}
+		}
+	}
+
+	return result, nil
+}
+
+var (
+	gradleIncludeRegex    = regexp.MustCompile(`(?m)^\s*include\b\s*\(?([^\n)]*)\)?`)
+	gradleProjectDirRegex = regexp.MustCompile(`project\(\s*["']:?([^"']+)["']\s*\)\.projectDir\s*=\s*(?:file\(|new\s+File\(\s*settingsDir\s*,)\s*["']([^"']+)["']`)
+	gradleSrcDirsRegex    = regexp.MustCompile(`\bsrcDirs?\b\s*(?:\+?=)?\s*[\[(]?([^\])\n]*)`)
+	quotedStringRegex     = regexp.MustCompile(`["']([^"']+)["']`)
+)
+
+// gradleIncludes returns the directories of the projects included by a settings script
+func gradleIncludes(content string) []string {
+	projectDirs := make(map[string]string)
+	for _, match := range gradleProjectDirRegex.FindAllStringSubmatch(content, -1) {
+		projectDirs[strings.ReplaceAll(match[1], ":", "/")] = match[2]
+	}
+
+	var dirs []string
+	for _, match := range gradleIncludeRegex.FindAllStringSubmatch(content, -1) {
+		for _, quoted := range quotedStringRegex.FindAllStringSubmatch(match[1], -1) {
+			path := strings.ReplaceAll(strings.TrimPrefix(quoted[1], ":"), ":", "/")
+			if dir, ok := projectDirs[path]; ok {
+				path = dir
+			}
+			dirs = append(dirs, path)
+		}
+	}
+	return dirs
+}
+
+// gradleSourceDirs returns the source directories declared with srcDir or srcDirs
+func gradleSourceDirs(content string) []string {
+	var dirs []string
+	for _, match := range gradleSrcDirsRegex.FindAllStringSubmatch(content, -1) {
+		for _, quoted := range quotedStringRegex.FindAllStringSubmatch(match[1], -1) {
+			dirs = append(dirs, quoted[1])
+		}
+	}
+	return dirs
+}
@@ -0,0 +1,212 @@
+package project
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func writeFiles(t *testing.T, root string, files map[string]string) {
+	t.Helper()
+	for name, content := range files {
+		path := filepath.Join(root, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func TestDiscoverMavenModules(t *testing.T) {
+	root := t.TempDir()
+	writeFiles(t, root, map[string]string{
+		"pom.xml": `<project xmlns="http://maven.apache.org/POM/4.0.0">
+  <modules>
+    <module>core</module>
+    <module>app</module>
+  </modules>
+</project>`,
+		"core/pom.xml": `<project>
+  <build>
+    <plugins>
+      <plugin>
+        <artifactId>build-helper-maven-plugin</artifactId>
+        <executions>
+          <execution>
+            <configuration>
+              <sources>
+                <source>src/generated/java</source>
+              </sources>
+            </configuration>
+          </execution>
+        </executions>
+      </plugin>
+    </plugins>
+  </build>
+</project>`,
+		"core/src/main/java/A.java":      "class A {}",
+		"core/src/test/java/ATest.java":  "class ATest {}",
+		"core/src/generated/java/G.java": "class G {}",
+		"app/pom.xml": `<project>
+  <build>
+    <sourceDirectory>java</sourceDirectory>
+  </build>
+</project>`,
+		"app/java/B.java": "class B {}",
+	})
+
+	modules, err := DiscoverFS(os.DirFS(root), DefaultSourceDirs)
+	if err != nil {
+		t.Fatalf("DiscoverFS failed: %v", err)
+	}
+
+	var paths []string
+	for _, module := range modules {
+		paths = append(paths, module.Path)
+	}
+	if !reflect.DeepEqual(paths, []string{".", "app", "core"}) {
+		t.Fatalf("Unexpected modules %v", paths)
+	}
+
+	if got := modules[1].SourceDirs; !reflect.DeepEqual(got, []string{"app/java"}) {
+		t.Errorf("Unexpected app source dirs %v", got)
+	}
+	expectedCore := []string{"core/src/generated/java", "core/src/main/java", "core/src/test/java"}
+	if got := modules[2].SourceDirs; !reflect.DeepEqual(got, expectedCore) {
+		t.Errorf("Unexpected core source dirs %v", got)
+	}
+	if len(modules[2].BuildFiles) != 1 {
+		t.Errorf("Expected core pom.xml to be found, got %v", modules[2].BuildFiles)
+	}
+}
+
+func TestDiscoverGradleModules(t *testing.T) {
+	root := t.TempDir()
+	writeFiles(t, root, map[string]string{
+		"settings.gradle.kts": `rootProject.name = "example"
+include("lib", ":services:api")
+include(":legacy")
+project(":legacy").projectDir = file("old/legacy")
+`,
+		"build.gradle.kts":                    `plugins { java }`,
+		"lib/build.gradle":                    `sourceSets { main { java { srcDirs = ['src/main/java', 'src/extra/java'] } } }`,
+		"lib/src/main/java/A.java":            "class A {}",
+		"lib/src/extra/java/E.java":           "class E {}",
+		"services/api/build.gradle.kts":       `plugins { java }`,
+		"services/api/src/main/java/B.java":   "class B {}",
+		"old/legacy/build.gradle":             `apply plugin: 'java'`,
+		"old/legacy/src/test/java/CTest.java": "class CTest {}",
+	})
+
+	modules, err := DiscoverFS(os.DirFS(root), DefaultSourceDirs)
+	if err != nil {
+		t.Fatalf("DiscoverFS failed: %v", err)
+	}
+
+	found := make(map[string][]string)
+	for _, module := range modules {
+		found[module.Path] = module.SourceDirs
+	}
+
+	expected := map[string][]string{
+		".":            nil,
+		"lib":          {"lib/src/extra/java", "lib/src/main/java"},
+		"services/api": {"services/api/src/main/java"},
+		"old/legacy":   {"old/legacy/src/test/java"},
+	}
+	if !reflect.DeepEqual(found, expected) {
+		t.Errorf("Expected %v, got %v", expected, found)
+	}
+}
+
+func TestDiscoverSkipsNestedSourceDirs(t *testing.T) {
+	root := t.TempDir()
+	writeFiles(t, root, map[string]string{
+		"src/main/java/A.java": "class A {}",
+		"src/test/java/B.java": "class B {}",
+	})
+
+	modules, err := DiscoverFS(os.DirFS(root), []string{"src", "src/main/java"})
+	if err != nil {
+		t.Fatalf("DiscoverFS failed: %v", err)
+	}
+	if got := modules[0].SourceDirs; !reflect.DeepEqual(got, []string{"src"}) {
+		t.Errorf("Expected only the outer source dir, got %v", got)
+	}
+}
+
+func TestDiscoverLatin1Pom(t *testing.T) {
+	modules, err := DiscoverFS(os.DirFS("testdata/latin1"), DefaultSourceDirs)
+	if err != nil {
+		t.Fatalf("DiscoverFS failed: %v", err)
+	}
+	var paths []string
+	for _, module := range modules {
+		paths = append(paths, module.Path)
+	}
+	if expected := []string{".", "core"}; !reflect.DeepEqual(paths, expected) {
+		t.Errorf("Expected modules %v, got %v", expected, paths)
+	}
+}
+
+func TestDiscoverReportsDirsOutsideProject(t *testing.T) {
+	root := t.TempDir()
+	writeFiles(t, root, map[string]string{
+		"pom.xml": `<project>
+  <modules>
+    <module>core</module>
+    <module>../shared</module>
+  </modules>
+  <build>
+    <sourceDirectory>/opt/sources</sourceDirectory>
+  </build>
+</project>`,
+		"core/pom.xml":              "<project/>",
+		"core/src/main/java/A.java": "class A {}",
+	})
+
+	modules, err := DiscoverFS(os.DirFS(root), DefaultSourceDirs)
+	if err != nil {
+		t.Fatalf("DiscoverFS failed: %v", err)
+	}
+	if len(modules) != 2 || modules[1].Path != "core" {
+		t.Fatalf("Expected the root and core modules, got %+v", modules)
+	}
+	if expected := []string{"../shared", "/opt/sources"}; !reflect.DeepEqual(modules[0].Outside, expected) {
+		t.Errorf("Expected %v outside the project, got %v", expected, modules[0].Outside)
+	}
+	if modules[1].Outside != nil {
+		t.Errorf("Expected nothing outside the project for core, got %v", modules[1].Outside)
+	}
+}
+
+func TestDiscoverKeepsModulesWithUnparseablePoms(t *testing.T) {
+	root := t.TempDir()
+	writeFiles(t, root, map[string]string{
+		"pom.xml":                   "<project>\n  <modules>\n    <module>core</module>\n  </modules>\n</project>",
+		"core/pom.xml":              "<project><build></project>",
+		"core/src/main/java/A.java": "class A {}",
+	})
+
+	modules, err := DiscoverFS(os.DirFS(root), DefaultSourceDirs)
+	if err != nil {
+		t.Fatalf("DiscoverFS failed: %v", err)
+	}
+	if len(modules) != 2 || modules[1].Path != "core" {
+		t.Fatalf("Expected the root and core modules, got %+v", modules)
+	}
+	core := modules[1]
+	if !reflect.DeepEqual(core.SourceDirs, []string{"core/src/main/java"}) {
+		t.Errorf("Expected the default source directories of core, got %v", core.SourceDirs)
+	}
+	if len(core.BuildFileErrors) != 1 || !strings.Contains(core.BuildFileErrors[0].Error(), "core/pom.xml") {
+		t.Errorf("Expected the parse error of core/pom.xml, got %v", core.BuildFileErrors)
+	}
+	if modules[0].BuildFileErrors != nil {
+		t.Errorf("Expected no errors for the root module, got %v", modules[0].BuildFileErrors)
+	}
+}
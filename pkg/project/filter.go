@@ -0,0 +1,320 @@
+package project
+
+import (
+	"bufio"
+	"io/fs"
+	"path"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"sync"
+)
+
+// IgnoreFileNames lists the files whose gitignore-style patterns exclude paths from a run
+var IgnoreFileNames = []string{
+	".gitignore",
+	".rewriteignore",
+}
+
+// OutputDirNames are build output directories whose sources are never migrated
+var OutputDirNames = []string{
+	"target",
+	"build",
+	"out",
+	"generated-sources",
+}
+
+// Filter decides which files below a project root take part in a run
+type Filter struct {
+	fsys     fs.FS
+	includes []string
+	excludes []string
+
+	mu      sync.Mutex
+	ignores map[string][]ignorePattern
+}
+
+// NewFilterFS creates a Filter for the project at the root of fsys. Paths passed to Accept
+// and SkipDir are slash-separated paths in fsys. Include and exclude globs are matched
+// against those paths; globs without a slash match the file name and ** matches any
+// number of directories.
+func NewFilterFS(fsys fs.FS, includes, excludes []string) *Filter {
+	return &Filter{
+		fsys:     fsys,
+		includes: includes,
+		excludes: excludes,
+		ignores:  make(map[string][]ignorePattern),
+	}
+}
+
+// Accept reports whether the file at path should be visited
+func (f *Filter) Accept(path string) bool {
+	rel, ok := f.relative(path)
+	if !ok {
+		return false
+	}
+
+	if len(f.includes) > 0 && !matchAnyGlob(f.includes, rel) {
+		return false
+	}
+	if matchAnyGlob(f.excludes, rel) {
+		return false
+	}
+
+	// A file cannot be re-included once one of its parent directories is ignored
+	segments := strings.Split(rel, "/")
+	for i := 1; i < len(segments); i++ {
+		if f.ignored(strings.Join(segments[:i], "/"), true) {
+			return false
+		}
+	}
+	return !f.ignored(rel, false)
+}
+
+// SkipDir reports whether the walk should not descend into the directory at path
+func (f *Filter) SkipDir(path string) bool {
+	rel, ok := f.relative(path)
+	if !ok || rel == "." {
+		return false
+	}
+	return f.ignored(rel, true)
+}
+
+// IsOutputDir reports whether a directory relative to its module lies in a build output folder
+func IsOutputDir(rel string) bool {
+	for _, segment := range strings.Split(filepath.ToSlash(rel), "/") {
+		for _, name := range OutputDirNames {
+			if segment == name {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// relative cleans the path of a file in fsys, rejecting paths outside of it
+func (f *Filter) relative(name string) (string, bool) {
+	rel := path.Clean(filepath.ToSlash(name))
+	if !fs.ValidPath(rel) {
+		return "", false
+	}
+	return rel, true
+}
+
+// ignored applies the ignore files of every directory from the root down to rel.
+// As in git, the last matching pattern wins.
+func (f *Filter) ignored(rel string, isDir bool) bool {
+	dirs := []string{"."}
+	segments := strings.Split(rel, "/")
+	for i := 1; i < len(segments); i++ {
+		dirs = append(dirs, strings.Join(segments[:i], "/"))
+	}
+
+	ignored := false
+	for _, dir := range dirs {
+		relToDir := rel
+		if dir != "." {
+			relToDir = strings.TrimPrefix(rel, dir+"/")
+		}
+		for _, pattern := range f.patterns(dir) {
+			if pattern.matches(relToDir, isDir) {
+				ignored = !pattern.negate
+			}
+		}
+	}
+	return ignored
+}
+
+// patterns returns the cached ignore patterns declared in dir
+func (f *Filter) patterns(dir string) []ignorePattern {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if patterns, ok := f.ignores[dir]; ok {
+		return patterns
+	}
+
+	var patterns []ignorePattern
+	for _, name := range IgnoreFileNames {
+		patterns = append(patterns, readIgnoreFile(f.fsys, path.Join(dir, name))...)
+	}
+	f.ignores[dir] = patterns
+	return patterns
+}
+
+// ignorePattern is a single line of a gitignore-style file
+type ignorePattern struct {
+	glob     string
+	negate   bool
+	dirOnly  bool
+	anchored bool
+}
+
+func readIgnoreFile(fsys fs.FS, name string) []ignorePattern {
+	file, err := fsys.Open(name)
+	if err != nil {
+		return nil
+	}
+	defer file.Close()
+
+	var patterns []ignorePattern
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		line := strings.TrimRight(scanner.Text(), " \t\r")
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		var pattern ignorePattern
+		if strings.HasPrefix(line, "!") {
+			pattern.negate = true
+			line = line[1:]
+		}
+		line = strings.TrimPrefix(line, `\`)
+		if strings.HasSuffix(line, "/") {
+			pattern.dirOnly = true
+			line = strings.TrimSuffix(line, "/")
+		}
+		if strings.Contains(line, "/") {
+			pattern.anchored = true
+			line = strings.TrimPrefix(line, "/")
+		}
+		if line == "" {
+			continue
+		}
+
+		pattern.glob = line
+		patterns = append(patterns, pattern)
+	}
+	return patterns
+}
+
+func (p ignorePattern) matches(rel string, isDir bool) bool {
+	if p.dirOnly && !isDir {
+		return false
+	}
+	if p.anchored {
+		return matchGlob(p.glob, rel)
+	}
+	matched, _ := path.Match(p.glob, path.Base(rel))
+	return matched
+}
+
+func matchAnyGlob(globs []string, rel string) bool {
+	for _, glob := range globs {
+		glob = strings.TrimPrefix(filepath.ToSlash(glob), "./")
+		if !strings.Contains(glob, "/") {
+			if matched, _ := path.Match(glob, path.Base(rel)); matched {
+				return true
+			}
+			continue
+		}
+		if matchGlob(strings.TrimPrefix(glob, "/"), rel) {
+			return true
+		}
+	}
+	return false
+}
+
+// matchGlob matches a slash-separated path against a glob in which ** spans directories
+func matchGlob(glob, rel string) bool {
+	return matchSegments(strings.Split(glob, "/"), strings.Split(rel, "/"))
+}
+
+func matchSegments(globs, segments []string) bool {
+	for len(globs) > 0 {
+		if globs[0] == "**" {
+			for i := 0; i <= len(segments); i++ {
+				if matchSegments(globs[1:], segments[i:]) {
+					return true
+				}
+			}
+			return false
+		}
+		if len(segments) == 0 {
+			return false
+		}
+		if matched, _ := path.Match(globs[0], segments[0]); !matched {
+			return false
+		}
+		globs, segments = globs[1:], segments[1:]
+	}
+	return len(segments) == 0
+}
+
+var (
+	generatedAnnotationRegex = regexp.MustCompile(`@(?:javax\.annotation\.(?:processing\.)?|jakarta\.annotation\.)?Generated\b`)
+	doNotEditRegex           = regexp.MustCompile(`(?i)\bdo not edit\b`)
+	typeDeclarationRegex     = regexp.MustCompile(`\b(?:class|interface|enum|record)\s+[A-Za-z_$]`)
+)
+
+// IsGenerated reports whether Java source content was produced by a code generator, either
+// through a "DO NOT EDIT" marker in the comments the file starts with or a @Generated
+// annotation on its first type. Comments and annotations further down are not considered,
+// so a generated method or a mention in a Javadoc comment does not count.
+func IsGenerated(content string) bool {
+	code := blankCommentsAndLiterals(content)
+
+	headerEnd := len(code) - len(strings.TrimLeft(code, " \t\r\n\f"))
+	if doNotEditRegex.MatchString(content[:headerEnd]) {
+		return true
+	}
+
+	declaration := typeDeclarationRegex.FindStringIndex(code)
+	if declaration == nil {
+		return false
+	}
+	return generatedAnnotationRegex.MatchString(code[:declaration[0]])
+}
+
+// blankCommentsAndLiterals replaces the comments and the string and character literals of
+// Java source content with spaces, keeping line breaks so offsets stay the same
+func blankCommentsAndLiterals(content string) string {
+	code := []byte(content)
+	blank := func(start, end int) {
+		for i := start; i < end; i++ {
+			if code[i] != '\n' {
+				code[i] = ' '
+			}
+		}
+	}
+
+	for i := 0; i < len(code); i++ {
+		end := -1
+		switch {
+		case strings.HasPrefix(content[i:], "//"):
+			end = strings.IndexByte(content[i:], '\n')
+		case strings.HasPrefix(content[i:], "/*"):
+			if end = strings.Index(content[i+2:], "*/"); end >= 0 {
+				end += 4
+			}
+		case strings.HasPrefix(content[i:], `"""`):
+			if end = strings.Index(content[i+3:], `"""`); end >= 0 {
+				end += 6
+			}
+		case content[i] == '"' || content[i] == '\'':
+			end = literalEnd(content[i:])
+		default:
+			continue
+		}
+		if end < 0 {
+			end = len(content) - i
+		}
+		blank(i, i+end)
+		i += end - 1
+	}
+	return string(code)
+}
+
+// literalEnd returns the length of the string or character literal content starts with
+func literalEnd(content string) int {
+	for i := 1; i < len(content); i++ {
+		switch content[i] {
+		case '\\':
+			i++
+		case content[0], '\n':
+			return i + 1
+		}
+	}
+	return len(content)
+}
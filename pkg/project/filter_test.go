@@ -0,0 +1,88 @@
+package project
+
+import (
+	"os"
+	"testing"
+)
+
+func TestFilterGlobs(t *testing.T) {
+	filter := NewFilterFS(os.DirFS(t.TempDir()), []string{"core/**"}, []string{"**/legacy/**", "*IT.java"})
+
+	tests := map[string]bool{
+		"core/src/main/java/A.java":        true,
+		"core/src/main/java/legacy/B.java": false,
+		"core/src/test/java/AIT.java":      false,
+		"app/src/main/java/C.java":         false,
+	}
+	for rel, expected := range tests {
+		if got := filter.Accept(rel); got != expected {
+			t.Errorf("Accept(%s) = %v, expected %v", rel, got, expected)
+		}
+	}
+}
+
+func TestFilterIgnoreFiles(t *testing.T) {
+	root := t.TempDir()
+	writeFiles(t, root, map[string]string{
+		".gitignore":           "# comment\n*.gen.java\n/tmp/\nbuild/\n!Keep.gen.java\n",
+		"core/.rewriteignore":  "src/main/java/skip/\n",
+		"core/src/main/java/A": "",
+	})
+	filter := NewFilterFS(os.DirFS(root), nil, nil)
+
+	tests := map[string]bool{
+		"core/src/main/java/A.java":          true,
+		"core/src/main/java/A.gen.java":      false,
+		"core/src/main/java/Keep.gen.java":   true,
+		"tmp/A.java":                         false,
+		"core/tmp/A.java":                    true,
+		"core/build/A.java":                  false,
+		"core/src/main/java/skip/A.java":     false,
+		"app/src/main/java/skip/A.java":      true,
+		"core/src/main/java/build/Tool.java": false,
+	}
+	for rel, expected := range tests {
+		if got := filter.Accept(rel); got != expected {
+			t.Errorf("Accept(%s) = %v, expected %v", rel, got, expected)
+		}
+	}
+
+	if !filter.SkipDir("core/build") {
+		t.Error("Expected core/build to be skipped")
+	}
+}
+
+func TestIsOutputDir(t *testing.T) {
+	tests := map[string]bool{
+		"src/main/java":                               false,
+		"target/generated-sources/annotations":        true,
+		"build/generated/sources/annotationProcessor": true,
+		"out/production":                              true,
+	}
+	for rel, expected := range tests {
+		if got := IsOutputDir(rel); got != expected {
+			t.Errorf("IsOutputDir(%s) = %v, expected %v", rel, got, expected)
+		}
+	}
+}
+
+func TestIsGenerated(t *testing.T) {
+	tests := map[string]bool{
+		"// Code generated by protoc. DO NOT EDIT.\npackage a;\nclass A {}":        true,
+		"package a;\n@javax.annotation.Generated(\"tool\")\nclass A {}":            true,
+		"package a;\nimport jakarta.annotation.Generated;\n@Generated\nclass A {}": true,
+		"package a;\n@Entity\nclass A {\n    @GeneratedValue\n    Long id;\n}":     false,
+		"package a;\nclass A {}": false,
+		"/*\n * Generated file, do not edit!\n */\npackage a;\nclass A {}":                     true,
+		"package a;\n@Generated(value = \"tool\", date = \"today\") public class A {}":         true,
+		"package a;\n/** Unlike {@link Generated} sources, edit freely. */\nclass A {}":        false,
+		"package a;\nclass A {\n    @Generated\n    void builder() {}\n}":                      false,
+		"package a;\nclass A {\n    // Do not edit the constant below\n    int x = 1;\n}":      false,
+		"package a;\nclass A {\n    String s = \"// DO NOT EDIT\";\n}\n@Generated\nclass B {}": false,
+	}
+	for content, expected := range tests {
+		if got := IsGenerated(content); got != expected {
+			t.Errorf("IsGenerated(%q) = %v, expected %v", content, got, expected)
+		}
+	}
+}
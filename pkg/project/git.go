@@ -0,0 +1,123 @@
+package project
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"os/exec"
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+// ChangedFiles lists the Java and build files below root that differ from ref in the
+// local git repository, including uncommitted and untracked files. Paths are relative
+// to root and use forward slashes.
+func ChangedFiles(root, ref string) (map[string]bool, error) {
+	// Resolve the ref first so a typo is reported as such rather than as a path
+	if _, err := git(root, "rev-parse", "--verify", "--quiet", ref+"^{commit}"); err != nil {
+		return nil, fmt.Errorf("unknown git revision %q", ref)
+	}
+
+	changed, err := git(root, "diff", "--name-only", "--relative", "--no-renames", "--diff-filter=d", ref, "--")
+	if err != nil {
+		return nil, err
+	}
+	untracked, err := git(root, "ls-files", "--others", "--exclude-standard")
+	if err != nil {
+		return nil, err
+	}
+
+	files := make(map[string]bool)
+	for _, name := range append(changed, untracked...) {
+		if isMigratable(name) {
+			files[name] = true
+		}
+	}
+	return files, nil
+}
+
+// isMigratable reports whether name is a Java source or a build file
+func isMigratable(name string) bool {
+	if strings.HasSuffix(name, ".java") {
+		return true
+	}
+	base := path.Base(name)
+	for _, buildFile := range BuildFileNames {
+		if base == buildFile {
+			return true
+		}
+	}
+	return false
+}
+
+// IsClean reports whether the git working tree containing root has no uncommitted
+// changes to tracked files. Untracked files are ignored.
+func IsClean(root string) (bool, error) {
+	status, err := git(root, "status", "--porcelain", "--untracked-files=no")
+	if err != nil {
+		return false, err
+	}
+	return len(status) == 0, nil
+}
+
+// IgnoreDir creates dir, if it does not exist yet, with a .gitignore that keeps git from
+// tracking anything in it, so tool state stored in a project does not show up as untracked
+func IgnoreDir(dir string) error {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return err
+	}
+	ignore, err := os.OpenFile(filepath.Join(dir, ".gitignore"), os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0644)
+	if errors.Is(err, fs.ErrExist) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if _, err := ignore.WriteString("*\n"); err != nil {
+		ignore.Close()
+		return err
+	}
+	return ignore.Close()
+}
+
+// Commit stages paths, which are relative to root, and commits them with message
+func Commit(root, message string, paths []string) error {
+	if _, err := git(root, append([]string{"add", "--"}, paths...)...); err != nil {
+		return err
+	}
+	_, err := gitWithInput(root, message, "commit", "--quiet", "--file=-")
+	return err
+}
+
+// git runs a git command in dir and returns the non-empty lines of its output
+func git(dir string, args ...string) ([]string, error) {
+	return gitWithInput(dir, "", args...)
+}
+
+// gitWithInput runs a git command in dir with input on its standard input
+func gitWithInput(dir, input string, args ...string) ([]string, error) {
+	cmd := exec.Command("git", append([]string{"-C", dir, "-c", "core.quotePath=false"}, args...)...)
+	cmd.Stdin = strings.NewReader(input)
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+
+	output, err := cmd.Output()
+	if err != nil {
+		message := strings.TrimSpace(stderr.String())
+		if message == "" {
+			message = err.Error()
+		}
+		return nil, fmt.Errorf("failed to run git %s: %s", args[0], message)
+	}
+
+	var lines []string
+	for _, line := range strings.Split(string(output), "\n") {
+		if line = strings.TrimSpace(line); line != "" {
+			lines = append(lines, line)
+		}
+	}
+	return lines, nil
+}
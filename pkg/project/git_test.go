@@ -0,0 +1,131 @@
+package project
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func runGit(t *testing.T, dir string, args ...string) {
+	t.Helper()
+	cmd := exec.Command("git", append([]string{"-C", dir}, args...)...)
+	cmd.Env = append(os.Environ(),
+		"GIT_AUTHOR_NAME=test", "GIT_AUTHOR_EMAIL=test@example.com",
+		"GIT_COMMITTER_NAME=test", "GIT_COMMITTER_EMAIL=test@example.com")
+	if output, err := cmd.CombinedOutput(); err != nil {
+		t.Fatalf("git %v failed: %v\n%s", args, err, output)
+	}
+}
+
+func TestChangedFiles(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git is not installed")
+	}
+
+	root := t.TempDir()
+	writeFiles(t, root, map[string]string{
+		"app/pom.xml":                        "<project/>",
+		"app/src/main/java/A.java":           "class A {}",
+		"app/src/main/java/B.java":           "class B {}",
+		"app/src/main/java/Removed.java":     "class Removed {}",
+		"app/src/main/resources/application": "x",
+	})
+	runGit(t, root, "init", "-q")
+	runGit(t, root, "add", "-A")
+	runGit(t, root, "commit", "-q", "-m", "initial")
+
+	writeFiles(t, root, map[string]string{
+		"app/src/main/java/A.java":           "class A { int x; }",
+		"app/src/main/java/New.java":         "class New {}",
+		"app/src/main/resources/application": "y",
+	})
+	if err := os.Remove(filepath.Join(root, "app/src/main/java/Removed.java")); err != nil {
+		t.Fatal(err)
+	}
+
+	// Paths are relative to the project directory, not the repository root
+	files, err := ChangedFiles(filepath.Join(root, "app"), "HEAD")
+	if err != nil {
+		t.Fatalf("ChangedFiles failed: %v", err)
+	}
+	want := map[string]bool{
+		"src/main/java/A.java":   true,
+		"src/main/java/New.java": true,
+	}
+	if !reflect.DeepEqual(files, want) {
+		t.Errorf("Expected %v, got %v", want, files)
+	}
+
+	if _, err := ChangedFiles(root, "no-such-ref"); err == nil {
+		t.Error("Expected an error for an unknown ref")
+	}
+}
+
+func TestCommit(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git is not installed")
+	}
+
+	root := t.TempDir()
+	writeFiles(t, root, map[string]string{"A.java": "class A {}", "B.java": "class B {}"})
+	runGit(t, root, "init", "-q")
+	runGit(t, root, "config", "user.name", "test")
+	runGit(t, root, "config", "user.email", "test@example.com")
+	runGit(t, root, "add", "-A")
+	runGit(t, root, "commit", "-q", "-m", "initial")
+
+	if clean, err := IsClean(root); err != nil || !clean {
+		t.Fatalf("Expected a clean tree, got %v, %v", clean, err)
+	}
+
+	writeFiles(t, root, map[string]string{"A.java": "class A { int x; }", "B.java": "class B { int y; }"})
+	if clean, _ := IsClean(root); clean {
+		t.Fatal("Expected a dirty tree")
+	}
+
+	if err := Commit(root, "Change A\n\nOnly A is committed.", []string{"A.java"}); err != nil {
+		t.Fatalf("Commit failed: %v", err)
+	}
+
+	// B was not staged, so it remains modified
+	status, err := git(root, "status", "--porcelain")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(status, []string{"M B.java"}) {
+		t.Errorf("Unexpected status after commit: %v", status)
+	}
+	message, err := git(root, "log", "-1", "--format=%s")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(message, []string{"Change A"}) {
+		t.Errorf("Unexpected commit subject %v", message)
+	}
+}
+
+func TestIgnoreDir(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git is not installed")
+	}
+
+	root := t.TempDir()
+	runGit(t, root, "init", "-q")
+	dir := filepath.Join(root, ".state")
+	for i := 0; i < 2; i++ {
+		if err := IgnoreDir(dir); err != nil {
+			t.Fatalf("IgnoreDir failed: %v", err)
+		}
+	}
+	writeFiles(t, dir, map[string]string{"run/Backup.java": "class Backup {}"})
+
+	status, err := git(root, "status", "--porcelain", "--untracked-files=all")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(status) != 0 {
+		t.Errorf("Expected the directory to be ignored, got status %q", status)
+	}
+}
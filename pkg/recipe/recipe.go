@@ -2,17 +2,107 @@ package recipe
 
 import (
 	"context"
+	"encoding/json"
+	"log/slog"
+	"sync"
 	"time"
 )
 
-// ExecutionContext holds context information for recipe execution
+// ExecutionContext holds context information for recipe execution.
+// Files may be visited concurrently, so recipes should access Properties
+// through GetProperty, PutProperty and ComputeProperty.
 type ExecutionContext struct {
 	context.Context
 	Properties map[string]interface{}
+
+	logger *slog.Logger
+	// parent is the context a recipe's context was derived from; it owns the properties
+	parent *ExecutionContext
+
+	mu sync.Mutex
+}
+
+// NewExecutionContext creates an ExecutionContext with empty properties
+func NewExecutionContext(ctx context.Context) *ExecutionContext {
+	return &ExecutionContext{
+		Context:    ctx,
+		Properties: make(map[string]interface{}),
+	}
+}
+
+// SetLogger sets the logger recipes log to; slog.Default() is used until it is set
+func (c *ExecutionContext) SetLogger(logger *slog.Logger) {
+	c.logger = logger
+}
+
+// Logger returns the logger for the running recipe. While a recipe visits a file, its
+// records carry the recipe name.
+func (c *ExecutionContext) Logger() *slog.Logger {
+	if c.logger == nil {
+		return slog.Default()
+	}
+	return c.logger
+}
+
+// forRecipe returns a context for visiting with r that logs with the name of r attached
+// and shares the properties of c
+func (c *ExecutionContext) forRecipe(r Recipe) *ExecutionContext {
+	root := c.root()
+	return &ExecutionContext{
+		Context:    c.Context,
+		Properties: root.Properties,
+		logger:     root.Logger().With("recipe", r.GetName()),
+		parent:     root,
+	}
+}
+
+func (c *ExecutionContext) root() *ExecutionContext {
+	if c.parent != nil {
+		return c.parent
+	}
+	return c
+}
+
+// GetProperty returns the property stored under key
+func (c *ExecutionContext) GetProperty(key string) (interface{}, bool) {
+	c = c.root()
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	value, ok := c.Properties[key]
+	return value, ok
+}
+
+// PutProperty stores value under key
+func (c *ExecutionContext) PutProperty(key string, value interface{}) {
+	c = c.root()
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.Properties == nil {
+		c.Properties = make(map[string]interface{})
+	}
+	c.Properties[key] = value
+}
+
+// ComputeProperty atomically replaces the property stored under key with the result of fn,
+// which receives the current value or nil
+func (c *ExecutionContext) ComputeProperty(key string, fn func(current interface{}) interface{}) interface{} {
+	c = c.root()
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.Properties == nil {
+		c.Properties = make(map[string]interface{})
+	}
+	value := fn(c.Properties[key])
+	c.Properties[key] = value
+	return value
 }
 
 // Recipe represents a migration recipe that can be applied to Java source code
 type Recipe interface {
+	GetName() string
 	GetDisplayName() string
 	GetDescription() string
 	GetEstimatedEffortPerOccurrence() time.Duration
@@ -79,11 +169,21 @@ type Parameter interface {
 
 // BaseRecipe provides a basic implementation of Recipe
 type BaseRecipe struct {
+	// Name is the fully qualified upstream OpenRewrite name, if the recipe has one
+	Name            string
 	DisplayName     string
 	Description     string
 	EstimatedEffort time.Duration
 }
 
+// GetName returns the fully qualified recipe name, falling back to the display name
+func (r *BaseRecipe) GetName() string {
+	if r.Name != "" {
+		return r.Name
+	}
+	return r.DisplayName
+}
+
 func (r *BaseRecipe) GetDisplayName() string {
 	return r.DisplayName
 }
@@ -144,3 +244,87 @@ func (v *CompositeVisitor) Visit(node SourceFile, ctx *ExecutionContext) (Source
 	}
 	return current, nil
 }
+
+// RecipeResult records the outcome of running a single recipe against a source file
+type RecipeResult struct {
+	Recipe Recipe
+	Before string
+	After  string
+	// PreconditionFailed is set when the recipe's applicability test rejected the file
+	PreconditionFailed bool
+	Duration           time.Duration
+}
+
+// Changed reports whether the recipe modified the file
+func (r RecipeResult) Changed() bool {
+	return r.Before != r.After
+}
+
+// Apply runs r against node like r.GetVisitor() would, visiting the children of
+// composite recipes one at a time so every change is attributed to the recipe that made it.
+// Recipes whose applicability test rejects the file are not visited.
+func Apply(r Recipe, node SourceFile, ctx *ExecutionContext) (SourceFile, []RecipeResult, error) {
+	visitor := r.GetVisitor()
+	if visitor == nil {
+		return node, nil, nil
+	}
+
+	if _, composite := visitor.(*CompositeVisitor); composite {
+		var results []RecipeResult
+		for _, child := range r.GetRecipeList() {
+			var childResults []RecipeResult
+			var err error
+			node, childResults, err = Apply(child, node, ctx)
+			if err != nil {
+				return nil, nil, err
+			}
+			results = append(results, childResults...)
+		}
+		return node, results, nil
+	}
+
+	before := node.GetContent()
+	if precondition := r.ApplicabilityTest(); precondition != nil && !precondition.Check(node) {
+		return node, []RecipeResult{{Recipe: r, Before: before, After: before, PreconditionFailed: true}}, nil
+	}
+
+	start := time.Now()
+	result, err := visitor.Visit(node, ctx.forRecipe(r))
+	if err != nil {
+		return nil, nil, err
+	}
+	return result, []RecipeResult{{
+		Recipe:   r,
+		Before:   before,
+		After:    result.GetContent(),
+		Duration: time.Since(start),
+	}}, nil
+}
+
+// Leaves returns the recipes that Apply attributes changes to, in the order they run
+func Leaves(r Recipe) []Recipe {
+	visitor := r.GetVisitor()
+	if visitor == nil {
+		return nil
+	}
+	if _, composite := visitor.(*CompositeVisitor); !composite {
+		return []Recipe{r}
+	}
+
+	var leaves []Recipe
+	for _, child := range r.GetRecipeList() {
+		leaves = append(leaves, Leaves(child)...)
+	}
+	return leaves
+}
+
+// Identity returns a key that tells recipes apart by name and options, so that leaves such as
+// UpgradeJavaVersion 11 and UpgradeJavaVersion 17 of a chain are not taken for one another
+func Identity(r Recipe) string {
+	// Recipe options are exported fields, so encoding the recipe captures its configuration
+	options, err := json.Marshal(r)
+	if err != nil {
+		return r.GetName()
+	}
+	return r.GetName() + "\x00" + string(options)
+}
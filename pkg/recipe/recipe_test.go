@@ -0,0 +1,171 @@
+package recipe
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"log/slog"
+	"strings"
+	"sync"
+	"testing"
+)
+
+func TestExecutionContextConcurrentProperties(t *testing.T) {
+	ctx := NewExecutionContext(context.Background())
+
+	var wg sync.WaitGroup
+	for i := 0; i < 50; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			ctx.ComputeProperty("count", func(current interface{}) interface{} {
+				count, _ := current.(int)
+				return count + 1
+			})
+		}()
+	}
+	wg.Wait()
+
+	value, ok := ctx.GetProperty("count")
+	if !ok || value.(int) != 50 {
+		t.Errorf("Expected count 50, got %v", value)
+	}
+}
+
+type replaceVisitor struct {
+	old, new string
+}
+
+func (v *replaceVisitor) Visit(node SourceFile, ctx *ExecutionContext) (SourceFile, error) {
+	return node.WithContent(strings.ReplaceAll(node.GetContent(), v.old, v.new)), nil
+}
+
+type replaceRecipe struct {
+	*BaseRecipe
+	visitor *replaceVisitor
+}
+
+func (r *replaceRecipe) GetVisitor() TreeVisitor {
+	return r.visitor
+}
+
+func TestApplyAttributesChangesToLeafRecipes(t *testing.T) {
+	first := &replaceRecipe{BaseRecipe: &BaseRecipe{Name: "first"}, visitor: &replaceVisitor{"a", "b"}}
+	unused := &replaceRecipe{BaseRecipe: &BaseRecipe{Name: "unused"}, visitor: &replaceVisitor{"z", "y"}}
+	second := &replaceRecipe{BaseRecipe: &BaseRecipe{Name: "second"}, visitor: &replaceVisitor{"b", "c"}}
+	composite := &CompositeRecipe{
+		BaseRecipe: &BaseRecipe{Name: "composite"},
+		Recipes: []Recipe{
+			first,
+			&CompositeRecipe{BaseRecipe: &BaseRecipe{Name: "nested"}, Recipes: []Recipe{unused, second}},
+		},
+	}
+
+	result, results, err := Apply(composite, NewSimpleSourceFile("file.txt", "a"), NewExecutionContext(context.Background()))
+	if err != nil {
+		t.Fatalf("Apply failed: %v", err)
+	}
+
+	if result.GetContent() != "c" {
+		t.Errorf("Expected content c, got %q", result.GetContent())
+	}
+	if len(results) != 3 {
+		t.Fatalf("Expected 3 results, got %d", len(results))
+	}
+	if results[0].Recipe.GetName() != "first" || results[0].Before != "a" || results[0].After != "b" {
+		t.Errorf("Unexpected first result %+v", results[0])
+	}
+	if results[1].Recipe.GetName() != "unused" || results[1].Changed() {
+		t.Errorf("Unexpected second result %+v", results[1])
+	}
+	if results[2].Recipe.GetName() != "second" || results[2].Before != "b" || results[2].After != "c" {
+		t.Errorf("Unexpected third result %+v", results[2])
+	}
+}
+
+type rejectAll struct{}
+
+func (rejectAll) Check(SourceFile) bool {
+	return false
+}
+
+type guardedRecipe struct {
+	*replaceRecipe
+}
+
+func (r *guardedRecipe) ApplicabilityTest() Precondition {
+	return rejectAll{}
+}
+
+func TestApplySkipsRecipesFailingPreconditions(t *testing.T) {
+	guarded := &guardedRecipe{&replaceRecipe{BaseRecipe: &BaseRecipe{Name: "guarded"}, visitor: &replaceVisitor{"a", "b"}}}
+
+	result, results, err := Apply(guarded, NewSimpleSourceFile("file.txt", "a"), NewExecutionContext(context.Background()))
+	if err != nil {
+		t.Fatalf("Apply failed: %v", err)
+	}
+	if result.GetContent() != "a" {
+		t.Errorf("Expected unchanged content, got %q", result.GetContent())
+	}
+	if len(results) != 1 || !results[0].PreconditionFailed {
+		t.Errorf("Expected a failed precondition, got %+v", results)
+	}
+}
+
+type loggingVisitor struct{}
+
+func (loggingVisitor) Visit(node SourceFile, ctx *ExecutionContext) (SourceFile, error) {
+	ctx.Logger().Info("visited", "path", node.GetPath())
+	ctx.PutProperty("visited", true)
+	return node, nil
+}
+
+type loggingRecipe struct {
+	*BaseRecipe
+}
+
+func (r *loggingRecipe) GetVisitor() TreeVisitor {
+	return loggingVisitor{}
+}
+
+func TestApplyLogsWithRecipeName(t *testing.T) {
+	var logs bytes.Buffer
+	ctx := NewExecutionContext(context.Background())
+	ctx.SetLogger(slog.New(slog.NewJSONHandler(&logs, nil)))
+
+	if _, _, err := Apply(&loggingRecipe{&BaseRecipe{Name: "logging"}}, NewSimpleSourceFile("file.txt", "a"), ctx); err != nil {
+		t.Fatalf("Apply failed: %v", err)
+	}
+
+	var record map[string]interface{}
+	if err := json.Unmarshal(logs.Bytes(), &record); err != nil {
+		t.Fatalf("Expected a JSON log record, got %q: %v", logs.String(), err)
+	}
+	if record["recipe"] != "logging" || record["path"] != "file.txt" {
+		t.Errorf("Unexpected log record %v", record)
+	}
+	if visited, _ := ctx.GetProperty("visited"); visited != true {
+		t.Error("Expected properties set while visiting to be shared with the run")
+	}
+}
+
+type versionRecipe struct {
+	*BaseRecipe
+	Version int
+}
+
+func (r *versionRecipe) GetVisitor() TreeVisitor {
+	return &replaceVisitor{}
+}
+
+func TestIdentityIncludesOptions(t *testing.T) {
+	upgrade := func(version int) Recipe {
+		return &versionRecipe{BaseRecipe: &BaseRecipe{Name: "upgrade"}, Version: version}
+	}
+	if Identity(upgrade(11)) == Identity(upgrade(17)) {
+		t.Error("Expected recipes with other options to have another identity")
+	}
+	if Identity(upgrade(11)) != Identity(upgrade(11)) {
+		t.Error("Expected equal recipes to have the same identity")
+	}
+}
@@ -0,0 +1,110 @@
+package recipe
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+)
+
+// OptionDescriptor describes a configurable option of a registered recipe
+type OptionDescriptor struct {
+	Name        string
+	Description string
+	Required    bool
+}
+
+// Descriptor describes a recipe that can be instantiated by name
+type Descriptor struct {
+	Name        string
+	DisplayName string
+	Description string
+	Options     []OptionDescriptor
+	New         func(options map[string]string) (Recipe, error)
+}
+
+// OptionsFromArgs maps positional arguments onto the descriptor's options in declaration order
+func (d *Descriptor) OptionsFromArgs(args []string) (map[string]string, error) {
+	if len(args) > len(d.Options) {
+		return nil, fmt.Errorf("recipe %s accepts %d options, got %d", d.Name, len(d.Options), len(args))
+	}
+
+	options := make(map[string]string, len(args))
+	for i, arg := range args {
+		options[d.Options[i].Name] = arg
+	}
+	return options, nil
+}
+
+// Registry holds recipe descriptors keyed by their fully qualified name
+type Registry struct {
+	mu          sync.RWMutex
+	descriptors map[string]*Descriptor
+}
+
+// NewRegistry creates an empty Registry
+func NewRegistry() *Registry {
+	return &Registry{
+		descriptors: make(map[string]*Descriptor),
+	}
+}
+
+// Register adds a descriptor to the registry
+func (r *Registry) Register(d Descriptor) error {
+	if d.Name == "" || d.New == nil {
+		return fmt.Errorf("recipe descriptor requires a name and a constructor")
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, exists := r.descriptors[d.Name]; exists {
+		return fmt.Errorf("recipe %s is already registered", d.Name)
+	}
+	r.descriptors[d.Name] = &d
+	return nil
+}
+
+// Lookup returns the descriptor registered under name
+func (r *Registry) Lookup(name string) (*Descriptor, bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	d, ok := r.descriptors[name]
+	return d, ok
+}
+
+// Names returns the names of all registered recipes in sorted order
+func (r *Registry) Names() []string {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	names := make([]string, 0, len(r.descriptors))
+	for name := range r.descriptors {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// New instantiates the recipe registered under name with the given options
+func (r *Registry) New(name string, options map[string]string) (Recipe, error) {
+	d, ok := r.Lookup(name)
+	if !ok {
+		return nil, fmt.Errorf("unknown recipe %s", name)
+	}
+
+	known := make(map[string]bool, len(d.Options))
+	for _, option := range d.Options {
+		known[option.Name] = true
+		if _, set := options[option.Name]; option.Required && !set {
+			return nil, fmt.Errorf("recipe %s requires option %s", name, option.Name)
+		}
+	}
+	for option := range options {
+		if !known[option] {
+			return nil, fmt.Errorf("recipe %s has no option %s", name, option)
+		}
+	}
+
+	return d.New(options)
+}
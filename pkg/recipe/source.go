@@ -0,0 +1,42 @@
+package recipe
+
+// SimpleSourceFile implements SourceFile for non-Java files such as build scripts
+type SimpleSourceFile struct {
+	path    string
+	content string
+}
+
+// NewSimpleSourceFile creates a SimpleSourceFile from content
+func NewSimpleSourceFile(path, content string) *SimpleSourceFile {
+	return &SimpleSourceFile{
+		path:    path,
+		content: content,
+	}
+}
+
+func (s *SimpleSourceFile) GetPath() string {
+	return s.path
+}
+
+func (s *SimpleSourceFile) GetContent() string {
+	return s.content
+}
+
+func (s *SimpleSourceFile) GetClasses() []ClassDeclaration {
+	return nil
+}
+
+func (s *SimpleSourceFile) GetImports() []ImportDeclaration {
+	return nil
+}
+
+func (s *SimpleSourceFile) GetPackage() string {
+	return ""
+}
+
+func (s *SimpleSourceFile) WithContent(content string) SourceFile {
+	return &SimpleSourceFile{
+		path:    s.path,
+		content: content,
+	}
+}
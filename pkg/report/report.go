@@ -0,0 +1,202 @@
+package report
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"time"
+
+	"rewrite-migrate-java/pkg/diff"
+	"rewrite-migrate-java/pkg/recipe"
+)
+
+// SchemaVersion is incremented whenever a field is removed or changes meaning.
+// Fields may be added without changing the version.
+const SchemaVersion = 1
+
+// File statuses
+const (
+	StatusUnchanged = "unchanged"
+	StatusChanged   = "changed"
+	StatusSkipped   = "skipped"
+	StatusError     = "error"
+)
+
+// Report is the machine-readable record of a migration run
+type Report struct {
+	SchemaVersion int       `json:"schemaVersion"`
+	Tool          string    `json:"tool"`
+	Project       string    `json:"project"`
+	Recipe        RecipeRef `json:"recipe"`
+	StartedAt     time.Time `json:"startedAt"`
+	DurationMs    float64   `json:"durationMs"`
+	DryRun        bool      `json:"dryRun"`
+	Summary       Summary   `json:"summary"`
+	Files         []File    `json:"files"`
+}
+
+// RecipeRef identifies a recipe by its fully qualified and display names
+type RecipeRef struct {
+	Name        string `json:"name"`
+	DisplayName string `json:"displayName"`
+}
+
+// Summary aggregates the file results of a run
+type Summary struct {
+	FilesProcessed        int     `json:"filesProcessed"`
+	FilesChanged          int     `json:"filesChanged"`
+	FilesSkipped          int     `json:"filesSkipped"`
+	FilesFailed           int     `json:"filesFailed"`
+	LinesAdded            int     `json:"linesAdded"`
+	LinesRemoved          int     `json:"linesRemoved"`
+	EstimatedTimeSavedSec float64 `json:"estimatedTimeSavedSeconds"`
+}
+
+// File is the result of processing a single file
+type File struct {
+	Path       string `json:"path"`
+	Module     string `json:"module"`
+	Status     string `json:"status"`
+	Error      string `json:"error,omitempty"`
+	SkipReason string `json:"skipReason,omitempty"`
+	// DurationMs is the time spent reading, parsing and visiting the file
+	DurationMs           float64        `json:"durationMs"`
+	LinesAdded           int            `json:"linesAdded"`
+	LinesRemoved         int            `json:"linesRemoved"`
+	Recipes              []RecipeChange `json:"recipes,omitempty"`
+	SkippedPreconditions []string       `json:"skippedPreconditions,omitempty"`
+	// Decisions are the review decisions of an interactive run
+	Decisions []Decision `json:"decisions,omitempty"`
+}
+
+// Decision records whether a reviewer accepted a single hunk proposed by a recipe
+type Decision struct {
+	Recipe   string `json:"recipe"`
+	Hunk     string `json:"hunk"`
+	Accepted bool   `json:"accepted"`
+}
+
+// RecipeChange describes the change a single recipe made to a file
+type RecipeChange struct {
+	RecipeRef
+	Occurrences           int     `json:"occurrences"`
+	LinesAdded            int     `json:"linesAdded"`
+	LinesRemoved          int     `json:"linesRemoved"`
+	DurationMs            float64 `json:"durationMs"`
+	EstimatedTimeSavedSec float64 `json:"estimatedTimeSavedSeconds"`
+}
+
+// New creates an empty report for a run of r over project
+func New(tool, project string, r recipe.Recipe, dryRun bool) *Report {
+	return &Report{
+		SchemaVersion: SchemaVersion,
+		Tool:          tool,
+		Project:       project,
+		Recipe:        refOf(r),
+		StartedAt:     time.Now().UTC(),
+		DryRun:        dryRun,
+		Files:         []File{},
+	}
+}
+
+// NewFile builds a file entry from the per-recipe results of a run over it
+func NewFile(path, module string, duration time.Duration, results []recipe.RecipeResult) File {
+	file := File{
+		Path:       path,
+		Module:     module,
+		Status:     StatusUnchanged,
+		DurationMs: milliseconds(duration),
+	}
+
+	for _, result := range results {
+		if result.PreconditionFailed {
+			file.SkippedPreconditions = append(file.SkippedPreconditions, result.Recipe.GetName())
+			continue
+		}
+		if !result.Changed() {
+			continue
+		}
+
+		change := RecipeChange{
+			RecipeRef:  refOf(result.Recipe),
+			DurationMs: milliseconds(result.Duration),
+		}
+		for _, hunk := range diff.Hunks(result.Before, result.After, 0) {
+			change.Occurrences++
+			for _, line := range hunk.Lines {
+				switch line.Op {
+				case diff.Insert:
+					change.LinesAdded++
+				case diff.Delete:
+					change.LinesRemoved++
+				}
+			}
+		}
+		change.EstimatedTimeSavedSec = (time.Duration(change.Occurrences) * result.Recipe.GetEstimatedEffortPerOccurrence()).Seconds()
+
+		file.Status = StatusChanged
+		file.Recipes = append(file.Recipes, change)
+	}
+
+	// Count lines against the file as a whole, as several recipes may touch the same line
+	if file.Status == StatusChanged {
+		before := results[0].Before
+		after := results[len(results)-1].After
+		for _, hunk := range diff.Hunks(before, after, 0) {
+			file.LinesRemoved += hunk.OldLines
+			file.LinesAdded += hunk.NewLines
+		}
+	}
+	return file
+}
+
+// Add appends a file entry and updates the summary
+func (r *Report) Add(file File) {
+	r.Files = append(r.Files, file)
+
+	switch file.Status {
+	case StatusSkipped:
+		r.Summary.FilesSkipped++
+		return
+	case StatusError:
+		r.Summary.FilesFailed++
+		return
+	case StatusChanged:
+		r.Summary.FilesChanged++
+	}
+
+	r.Summary.FilesProcessed++
+	r.Summary.LinesAdded += file.LinesAdded
+	r.Summary.LinesRemoved += file.LinesRemoved
+	for _, change := range file.Recipes {
+		r.Summary.EstimatedTimeSavedSec += change.EstimatedTimeSavedSec
+	}
+}
+
+// Finish records the total duration of the run
+func (r *Report) Finish() {
+	r.DurationMs = milliseconds(time.Since(r.StartedAt))
+}
+
+// WriteFile writes the report as indented JSON to path
+func (r *Report) WriteFile(path string) error {
+	data, err := json.MarshalIndent(r, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode report: %w", err)
+	}
+	if err := os.WriteFile(path, append(data, '\n'), 0644); err != nil {
+		return fmt.Errorf("failed to write report %s: %w", path, err)
+	}
+	return nil
+}
+
+func refOf(r recipe.Recipe) RecipeRef {
+	return RecipeRef{
+		Name:        r.GetName(),
+		DisplayName: r.GetDisplayName(),
+	}
+}
+
+func milliseconds(d time.Duration) float64 {
+	return float64(d.Microseconds()) / 1000
+}
@@ -0,0 +1,102 @@
+package report
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"rewrite-migrate-java/pkg/recipe"
+)
+
+type namedRecipe struct {
+	*recipe.BaseRecipe
+}
+
+func (r *namedRecipe) GetVisitor() recipe.TreeVisitor {
+	return nil
+}
+
+func newNamedRecipe(name string, effort time.Duration) *namedRecipe {
+	return &namedRecipe{BaseRecipe: &recipe.BaseRecipe{
+		Name:            name,
+		DisplayName:     name + " display",
+		EstimatedEffort: effort,
+	}}
+}
+
+func TestNewFileAttributesChangesToRecipes(t *testing.T) {
+	rename := newNamedRecipe("rename", time.Minute)
+	guarded := newNamedRecipe("guarded", time.Minute)
+	unused := newNamedRecipe("unused", time.Minute)
+
+	original := "a\nb\nc\nd\n"
+	renamed := "A\nb\nC\nd\n"
+
+	file := NewFile("src/A.java", "core", 5*time.Millisecond, []recipe.RecipeResult{
+		{Recipe: rename, Before: original, After: renamed, Duration: time.Millisecond},
+		{Recipe: guarded, Before: renamed, After: renamed, PreconditionFailed: true},
+		{Recipe: unused, Before: renamed, After: renamed},
+	})
+
+	if file.Status != StatusChanged {
+		t.Fatalf("Expected changed status, got %s", file.Status)
+	}
+	if file.LinesAdded != 2 || file.LinesRemoved != 2 {
+		t.Errorf("Expected +2/-2 lines, got +%d/-%d", file.LinesAdded, file.LinesRemoved)
+	}
+	if len(file.Recipes) != 1 || file.Recipes[0].Name != "rename" {
+		t.Fatalf("Expected only the rename recipe, got %+v", file.Recipes)
+	}
+	if file.Recipes[0].Occurrences != 2 || file.Recipes[0].EstimatedTimeSavedSec != 120 {
+		t.Errorf("Unexpected recipe change %+v", file.Recipes[0])
+	}
+	if len(file.SkippedPreconditions) != 1 || file.SkippedPreconditions[0] != "guarded" {
+		t.Errorf("Expected guarded precondition to be skipped, got %v", file.SkippedPreconditions)
+	}
+}
+
+func TestReportSummaryAndSchema(t *testing.T) {
+	r := New("migrate", "/project", newNamedRecipe("root", 0), true)
+	r.Add(NewFile("A.java", ".", 0, []recipe.RecipeResult{
+		{Recipe: newNamedRecipe("rename", time.Minute), Before: "a\n", After: "b\n"},
+	}))
+	r.Add(NewFile("B.java", ".", 0, nil))
+	r.Add(File{Path: "G.java", Module: ".", Status: StatusSkipped, SkipReason: "generated source"})
+	r.Add(File{Path: "C.java", Module: ".", Status: StatusError, Error: "boom"})
+	r.Finish()
+
+	want := Summary{
+		FilesProcessed:        2,
+		FilesChanged:          1,
+		FilesSkipped:          1,
+		FilesFailed:           1,
+		LinesAdded:            1,
+		LinesRemoved:          1,
+		EstimatedTimeSavedSec: 60,
+	}
+	if r.Summary != want {
+		t.Errorf("Expected summary %+v, got %+v", want, r.Summary)
+	}
+
+	path := filepath.Join(t.TempDir(), "report.json")
+	if err := r.WriteFile(path); err != nil {
+		t.Fatalf("WriteFile failed: %v", err)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var decoded map[string]interface{}
+	if err := json.Unmarshal(data, &decoded); err != nil {
+		t.Fatalf("Report is not valid JSON: %v", err)
+	}
+	if decoded["schemaVersion"] != float64(SchemaVersion) {
+		t.Errorf("Unexpected schema version %v", decoded["schemaVersion"])
+	}
+	if files := decoded["files"].([]interface{}); len(files) != 4 {
+		t.Errorf("Expected 4 files, got %d", len(files))
+	}
+}
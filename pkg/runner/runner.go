@@ -0,0 +1,322 @@
+package runner
+
+import (
+	"fmt"
+	"io/fs"
+	"path"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"sync"
+	"testing/fstest"
+	"time"
+
+	"rewrite-migrate-java/pkg/cache"
+	"rewrite-migrate-java/pkg/java"
+	"rewrite-migrate-java/pkg/journal"
+	"rewrite-migrate-java/pkg/maven"
+	"rewrite-migrate-java/pkg/project"
+	"rewrite-migrate-java/pkg/recipe"
+	"rewrite-migrate-java/pkg/textfile"
+)
+
+// Options control which files of a project a run visits and how
+type Options struct {
+	// SourceDirs are scanned in every module; project.DefaultSourceDirs when empty
+	SourceDirs []string
+	// Includes and Excludes are globs as accepted by project.NewFilterFS
+	Includes []string
+	Excludes []string
+	// SkipGenerated skips build output folders and generated sources
+	SkipGenerated bool
+	// Jobs is the number of files transformed in parallel; runtime.NumCPU() when zero
+	Jobs int
+	// Cache, if not nil, skips files a previous run left unchanged
+	Cache *cache.Cache
+	// Progress, if not nil, is called as each file is done with the number of files done
+	// so far and in total. Calls are not concurrent but follow the order files finish in.
+	Progress func(result Result, done, total int)
+}
+
+// Result is the outcome of running a recipe on a single file
+type Result struct {
+	// Path is the slash-separated path of the file in the file system
+	Path string
+	// Module is the path of the module the file belongs to
+	Module string
+	// Before and After hold the file as stored, in its original encoding and line endings
+	Before string
+	After  string
+	Format textfile.Format
+
+	RecipeResults []recipe.RecipeResult
+	Duration      time.Duration
+
+	// Skipped is set for generated sources, which are not transformed
+	Skipped bool
+	// Cached is set when the cache knew the file to be unchanged
+	Cached bool
+	Err    error
+}
+
+// Changed reports whether the recipe changed the file
+func (r Result) Changed() bool {
+	return r.Err == nil && r.After != r.Before
+}
+
+// MapFS returns a file system holding files, keyed by slash-separated path
+func MapFS(files map[string]string) fs.FS {
+	fsys := make(fstest.MapFS, len(files))
+	for name, content := range files {
+		fsys[strings.TrimPrefix(path.Clean(name), "/")] = &fstest.MapFile{Data: []byte(content), Mode: 0644}
+	}
+	return fsys
+}
+
+// Run applies r to the sources and build files of every module of the project at the root
+// of fsys. Results are returned for every visited file in a stable order, whatever the
+// number of jobs; a file that fails to transform does not stop the others. Nothing is
+// written: see Write.
+func Run(ctx *recipe.ExecutionContext, r recipe.Recipe, fsys fs.FS, opts Options) ([]Result, error) {
+	if r.GetVisitor() == nil {
+		return nil, fmt.Errorf("no visitor available for recipe")
+	}
+
+	tasks, modules, err := listFiles(fsys, opts)
+	if err != nil {
+		return nil, err
+	}
+	for _, module := range modules {
+		for _, outside := range module.Outside {
+			ctx.Logger().Warn("Not visiting a module or source directory outside the project",
+				"module", module.Path, "path", outside)
+		}
+		for _, err := range module.BuildFileErrors {
+			ctx.Logger().Warn("Visiting the default source directories of a module whose build file cannot be parsed",
+				"module", module.Path, "error", err)
+		}
+	}
+	maven.SetReactor(ctx, loadReactor(fsys, modules))
+
+	jobs := opts.Jobs
+	if jobs < 1 {
+		jobs = runtime.NumCPU()
+	}
+
+	results := make([]Result, len(tasks))
+	indexes := make(chan int)
+
+	var mu sync.Mutex
+	done := 0
+
+	var wg sync.WaitGroup
+	for w := 0; w < jobs; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range indexes {
+				results[i] = transform(ctx, r, fsys, tasks[i], opts)
+				if opts.Progress != nil {
+					mu.Lock()
+					done++
+					opts.Progress(results[i], done, len(tasks))
+					mu.Unlock()
+				}
+			}
+		}()
+	}
+
+	for i := range tasks {
+		if ctx.Err() != nil {
+			break
+		}
+		indexes <- i
+	}
+	close(indexes)
+	wg.Wait()
+
+	if err := ctx.Err(); err != nil {
+		return nil, fmt.Errorf("run cancelled: %w", err)
+	}
+	return results, nil
+}
+
+// File is a file visited by a run
+type File struct {
+	Path   string
+	Module string
+}
+
+// listFiles lists the files a run visits, the Java files of every source directory accepted
+// by the filters of opts followed by the build files of each module, and the modules they
+// belong to
+func listFiles(fsys fs.FS, opts Options) ([]File, []project.Module, error) {
+	sourceDirs := project.DefaultSourceDirs
+	if len(opts.SourceDirs) > 0 {
+		sourceDirs = opts.SourceDirs
+	}
+
+	modules, err := project.DiscoverFS(fsys, sourceDirs)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to discover modules: %w", err)
+	}
+
+	filter := project.NewFilterFS(fsys, opts.Includes, opts.Excludes)
+
+	var files []File
+	for _, module := range modules {
+		for _, sourceDir := range module.SourceDirs {
+			if opts.SkipGenerated && project.IsOutputDir(relativePath(module.Dir, sourceDir)) {
+				continue
+			}
+			sourceFiles, err := findSourceFiles(fsys, sourceDir, filter)
+			if err != nil {
+				return nil, nil, fmt.Errorf("failed to find source files in module %s: %w", module.Path, err)
+			}
+			for _, name := range sourceFiles {
+				files = append(files, File{Path: name, Module: module.Path})
+			}
+		}
+		for _, buildFile := range module.BuildFiles {
+			if filter.Accept(buildFile) {
+				files = append(files, File{Path: buildFile, Module: module.Path})
+			}
+		}
+	}
+	return files, modules, nil
+}
+
+// loadReactor loads the POMs of every module, including those the filters exclude, so that
+// parents and inherited properties resolve whatever files are visited
+func loadReactor(fsys fs.FS, modules []project.Module) *maven.Reactor {
+	var poms []string
+	for _, module := range modules {
+		for _, buildFile := range module.BuildFiles {
+			if path.Base(buildFile) == "pom.xml" {
+				poms = append(poms, buildFile)
+			}
+		}
+	}
+	return maven.LoadReactor(fsys, poms)
+}
+
+// findSourceFiles returns the Java files below sourceDir accepted by filter, in lexical order
+func findSourceFiles(fsys fs.FS, sourceDir string, filter *project.Filter) ([]string, error) {
+	var names []string
+	err := fs.WalkDir(fsys, sourceDir, func(name string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+
+		if d.IsDir() {
+			if filter.SkipDir(name) {
+				return fs.SkipDir
+			}
+			return nil
+		}
+
+		if !strings.HasSuffix(name, ".java") || !filter.Accept(name) {
+			return nil
+		}
+
+		names = append(names, name)
+		return nil
+	})
+	return names, err
+}
+
+// transform applies the recipe to a single file. recipe.Apply requests fresh visitors per
+// file, so visitors need not be goroutine-safe. Files the cache knows to be unchanged are
+// not parsed at all.
+func transform(ctx *recipe.ExecutionContext, r recipe.Recipe, fsys fs.FS, file File, opts Options) Result {
+	start := time.Now()
+	result := Result{Path: file.Path, Module: file.Module}
+
+	content, format, err := textfile.ReadFS(fsys, file.Path)
+	if err != nil {
+		result.Err = err
+		return result
+	}
+	result.Format = format
+
+	before, err := format.Encode(content)
+	if err != nil {
+		result.Err = fmt.Errorf("failed to encode file %s: %w", file.Path, err)
+		return result
+	}
+	result.Before = string(before)
+	result.After = result.Before
+
+	if strings.HasSuffix(file.Path, ".java") && opts.SkipGenerated && project.IsGenerated(content) {
+		result.Skipped = true
+		return result
+	}
+	// A POM can change with the POMs that inherit from it, so POMs are never cached
+	fileCache := opts.Cache
+	if path.Base(file.Path) == "pom.xml" {
+		fileCache = nil
+	}
+	if fileCache != nil && fileCache.Unchanged(file.Path, []byte(content)) {
+		result.Cached = true
+		result.Duration = time.Since(start)
+		return result
+	}
+
+	sourceFile, err := java.ParseSource(file.Path, content)
+	if err != nil {
+		result.Err = err
+		return result
+	}
+
+	transformed, recipeResults, err := recipe.Apply(r, sourceFile, ctx)
+	if err != nil {
+		result.Err = fmt.Errorf("failed to transform file %s: %w", file.Path, err)
+		return result
+	}
+	result.RecipeResults = recipeResults
+	result.Duration = time.Since(start)
+
+	// Keep the file's original encoding and line endings
+	after, err := format.Encode(transformed.GetContent())
+	if err != nil {
+		result.Err = fmt.Errorf("failed to encode file %s: %w", file.Path, err)
+		return result
+	}
+	result.After = string(after)
+
+	if !result.Changed() && fileCache != nil {
+		if err := fileCache.MarkUnchanged(file.Path, []byte(content)); err != nil {
+			result.Err = err
+		}
+	}
+	return result
+}
+
+// Write stores every changed result in the project at root. Files are written through
+// runJournal, so that the run can be undone, unless it is nil.
+func Write(root string, results []Result, runJournal *journal.Journal) error {
+	for _, result := range results {
+		if !result.Changed() {
+			continue
+		}
+		name := filepath.Join(root, filepath.FromSlash(result.Path))
+		if runJournal != nil {
+			if err := runJournal.Write(name, []byte(result.Before), []byte(result.After), result.Format.Mode); err != nil {
+				return err
+			}
+			continue
+		}
+		if err := textfile.WriteBytes(name, []byte(result.After), result.Format.Mode); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// relativePath returns name relative to dir, both slash-separated paths in a file system
+func relativePath(dir, name string) string {
+	if dir == "." {
+		return name
+	}
+	return strings.TrimPrefix(name, dir+"/")
+}
@@ -0,0 +1,181 @@
+package runner
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"rewrite-migrate-java/pkg/journal"
+	"rewrite-migrate-java/pkg/migrate"
+	"rewrite-migrate-java/pkg/recipe"
+)
+
+const base64Source = `package com.example;
+
+import sun.misc.BASE64Encoder;
+
+public class Example {
+    public String encode(byte[] data) {
+        return new BASE64Encoder().encode(data);
+    }
+}
+`
+
+func TestRunInMemory(t *testing.T) {
+	files := MapFS(map[string]string{
+		"pom.xml":      "<project>\n  <modules>\n    <module>core</module>\n  </modules>\n</project>\n",
+		"core/pom.xml": "<project/>\n",
+		"core/src/main/java/com/example/Example.java": base64Source,
+		"core/src/main/java/com/example/Plain.java":   "package com.example;\nclass Plain {}\n",
+		"core/src/main/java/com/example/Gen.java":     "// DO NOT EDIT\n" + base64Source,
+		"core/notes/Stray.java":                       base64Source,
+	})
+
+	var progress []int
+	results, err := Run(recipe.NewExecutionContext(context.Background()), migrate.NewUseJavaUtilBase64("", false), files, Options{
+		SkipGenerated: true,
+		Jobs:          2,
+		Progress: func(result Result, done, total int) {
+			progress = append(progress, done, total)
+		},
+	})
+	if err != nil {
+		t.Fatalf("Run failed: %v", err)
+	}
+
+	var paths []string
+	for _, result := range results {
+		paths = append(paths, result.Path)
+		if result.Err != nil {
+			t.Errorf("Unexpected error for %s: %v", result.Path, result.Err)
+		}
+	}
+	expected := []string{
+		"pom.xml",
+		"core/src/main/java/com/example/Example.java",
+		"core/src/main/java/com/example/Gen.java",
+		"core/src/main/java/com/example/Plain.java",
+		"core/pom.xml",
+	}
+	if !reflect.DeepEqual(paths, expected) {
+		t.Fatalf("Expected %v, got %v", expected, paths)
+	}
+
+	if !reflect.DeepEqual(progress, []int{1, 5, 2, 5, 3, 5, 4, 5, 5, 5}) {
+		t.Errorf("Unexpected progress %v", progress)
+	}
+
+	example, generated, plain := results[1], results[2], results[3]
+	if !example.Changed() || example.Module != "core" || !strings.Contains(example.After, "import java.util.Base64;") {
+		t.Errorf("Expected Example.java to be migrated, got %+v", example)
+	}
+	if !generated.Skipped || generated.Changed() {
+		t.Errorf("Expected the generated source to be skipped, got %+v", generated)
+	}
+	if plain.Changed() {
+		t.Errorf("Expected Plain.java to be unchanged")
+	}
+}
+
+func TestRunUpdatesInheritedJavaVersion(t *testing.T) {
+	parent := `<project>
+  <groupId>com.example</groupId>
+  <artifactId>parent</artifactId>
+  <version>1.0</version>
+  <modules>
+    <module>core</module>
+  </modules>
+  <properties>
+    <jdk>8</jdk>
+  </properties>
+</project>
+`
+	core := `<project>
+  <parent>
+    <groupId>com.example</groupId>
+    <artifactId>parent</artifactId>
+    <version>1.0</version>
+  </parent>
+  <artifactId>core</artifactId>
+  <build>
+    <plugins>
+      <plugin>
+        <artifactId>maven-compiler-plugin</artifactId>
+        <configuration>
+          <release>${jdk}</release>
+        </configuration>
+      </plugin>
+    </plugins>
+  </build>
+</project>
+`
+	files := MapFS(map[string]string{"pom.xml": parent, "core/pom.xml": core})
+
+	results, err := Run(recipe.NewExecutionContext(context.Background()), migrate.NewUpgradeJavaVersion(17), files, Options{})
+	if err != nil {
+		t.Fatalf("Run failed: %v", err)
+	}
+
+	for _, result := range results {
+		switch result.Path {
+		case "pom.xml":
+			if !strings.Contains(result.After, "<jdk>17</jdk>") {
+				t.Errorf("Expected the parent property to be updated, got:\n%s", result.After)
+			}
+		case "core/pom.xml":
+			if result.Changed() {
+				t.Errorf("Expected the module POM to keep its placeholder, got:\n%s", result.After)
+			}
+		}
+	}
+}
+
+func TestRunCancelled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	files := MapFS(map[string]string{"src/main/java/Example.java": base64Source})
+	if _, err := Run(recipe.NewExecutionContext(ctx), migrate.NewUseJavaUtilBase64("", false), files, Options{}); err == nil {
+		t.Error("Expected a cancelled run to fail")
+	}
+}
+
+func TestWrite(t *testing.T) {
+	root := t.TempDir()
+	path := filepath.Join(root, "src", "main", "java", "Example.java")
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, []byte(base64Source), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	results, err := Run(recipe.NewExecutionContext(context.Background()), migrate.NewUseJavaUtilBase64("", false), os.DirFS(root), Options{})
+	if err != nil {
+		t.Fatalf("Run failed: %v", err)
+	}
+	if data, _ := os.ReadFile(path); string(data) != base64Source {
+		t.Fatal("Run must not write files")
+	}
+
+	if err := Write(root, results, journal.Begin(root, "test")); err != nil {
+		t.Fatalf("Write failed: %v", err)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(data) != results[0].After || !strings.Contains(string(data), "java.util.Base64") {
+		t.Errorf("Unexpected content after Write:\n%s", data)
+	}
+
+	if _, err := journal.Undo(root); err != nil {
+		t.Fatalf("Undo failed: %v", err)
+	}
+	if data, _ := os.ReadFile(path); string(data) != base64Source {
+		t.Error("Expected the journaled write to be undone")
+	}
+}
@@ -0,0 +1,270 @@
+package sarif
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+
+	"rewrite-migrate-java/pkg/diff"
+	"rewrite-migrate-java/pkg/recipe"
+)
+
+const (
+	// Version is the SARIF specification version produced by this package
+	Version = "2.1.0"
+	// Schema is the JSON schema of SARIF 2.1.0 logs
+	Schema = "https://json.schemastore.org/sarif-2.1.0.json"
+	// SourceRoot is the uriBaseId that artifact locations are relative to
+	SourceRoot = "%SRCROOT%"
+)
+
+// Log is the root object of a SARIF file
+type Log struct {
+	Schema  string `json:"$schema"`
+	Version string `json:"version"`
+	Runs    []*Run `json:"runs"`
+}
+
+// Run holds the rules and results produced by one invocation of a tool
+type Run struct {
+	Tool    Tool     `json:"tool"`
+	Results []Result `json:"results"`
+
+	// ruleIndexes maps the recipe.Identity of each rule's recipe to its index
+	ruleIndexes map[string]int
+}
+
+type Tool struct {
+	Driver Driver `json:"driver"`
+}
+
+type Driver struct {
+	Name           string `json:"name"`
+	InformationURI string `json:"informationUri,omitempty"`
+	Rules          []Rule `json:"rules"`
+}
+
+type Rule struct {
+	ID               string   `json:"id"`
+	Name             string   `json:"name,omitempty"`
+	ShortDescription *Message `json:"shortDescription,omitempty"`
+	FullDescription  *Message `json:"fullDescription,omitempty"`
+}
+
+type Message struct {
+	Text string `json:"text"`
+}
+
+type Result struct {
+	RuleID    string     `json:"ruleId"`
+	RuleIndex int        `json:"ruleIndex"`
+	Level     string     `json:"level"`
+	Message   Message    `json:"message"`
+	Locations []Location `json:"locations"`
+	Fixes     []Fix      `json:"fixes,omitempty"`
+}
+
+type Location struct {
+	PhysicalLocation PhysicalLocation `json:"physicalLocation"`
+}
+
+type PhysicalLocation struct {
+	ArtifactLocation ArtifactLocation `json:"artifactLocation"`
+	Region           *Region          `json:"region,omitempty"`
+}
+
+type ArtifactLocation struct {
+	URI       string `json:"uri"`
+	URIBaseID string `json:"uriBaseId,omitempty"`
+}
+
+type Region struct {
+	StartLine   int `json:"startLine"`
+	StartColumn int `json:"startColumn,omitempty"`
+	EndLine     int `json:"endLine,omitempty"`
+	EndColumn   int `json:"endColumn,omitempty"`
+}
+
+type Fix struct {
+	Description     Message          `json:"description"`
+	ArtifactChanges []ArtifactChange `json:"artifactChanges"`
+}
+
+type ArtifactChange struct {
+	ArtifactLocation ArtifactLocation `json:"artifactLocation"`
+	Replacements     []Replacement    `json:"replacements"`
+}
+
+type Replacement struct {
+	DeletedRegion   Region           `json:"deletedRegion"`
+	InsertedContent *ArtifactContent `json:"insertedContent,omitempty"`
+}
+
+type ArtifactContent struct {
+	Text string `json:"text"`
+}
+
+// NewLog creates a log with a single run for the named tool
+func NewLog(toolName, informationURI string) *Log {
+	return &Log{
+		Schema:  Schema,
+		Version: Version,
+		Runs: []*Run{{
+			Tool: Tool{Driver: Driver{
+				Name:           toolName,
+				InformationURI: informationURI,
+				Rules:          []Rule{},
+			}},
+			Results:     []Result{},
+			ruleIndexes: make(map[string]int),
+		}},
+	}
+}
+
+// Write encodes the log as indented JSON
+func (l *Log) Write(w io.Writer) error {
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(l)
+}
+
+// AddRule registers r as a rule, if it is not registered yet, and returns its index. Rules
+// are told apart by recipe.Identity, so recipes of one name with other options, such as the
+// UpgradeJavaVersion steps of a chain, get rules of their own. The first of them has the
+// recipe name as its id and the others a numbered variant of it.
+func (r *Run) AddRule(rec recipe.Recipe) int {
+	identity := recipe.Identity(rec)
+	if index, ok := r.ruleIndexes[identity]; ok {
+		return index
+	}
+
+	id := rec.GetName()
+	for n := 2; r.hasRule(id); n++ {
+		id = fmt.Sprintf("%s#%d", rec.GetName(), n)
+	}
+	rule := Rule{
+		ID:               id,
+		Name:             rec.GetDisplayName(),
+		ShortDescription: &Message{Text: rec.GetDisplayName()},
+	}
+	if description := rec.GetDescription(); description != "" {
+		rule.FullDescription = &Message{Text: description}
+	}
+
+	index := len(r.Tool.Driver.Rules)
+	r.Tool.Driver.Rules = append(r.Tool.Driver.Rules, rule)
+	r.ruleIndexes[identity] = index
+	return index
+}
+
+// hasRule reports whether a rule with id is registered
+func (r *Run) hasRule(id string) bool {
+	for _, rule := range r.Tool.Driver.Rules {
+		if rule.ID == id {
+			return true
+		}
+	}
+	return false
+}
+
+// AddChanges adds one result per changed hunk of the recipe results for the file at path.
+// Recipes run in sequence, so their line numbers are mapped back onto the original file.
+func (r *Run) AddChanges(path string, results []recipe.RecipeResult) {
+	var origins []int
+	for _, change := range results {
+		if !change.Changed() {
+			continue
+		}
+		if origins == nil {
+			origins = identity(len(diff.SplitLines(change.Before)))
+		}
+
+		ruleIndex := r.AddRule(change.Recipe)
+		for _, hunk := range diff.Hunks(change.Before, change.After, 0) {
+			r.Results = append(r.Results, r.changeResult(path, change.Recipe, ruleIndex, hunk, origins))
+		}
+
+		// Carry the original line numbers over to the content produced by this recipe
+		next := diff.OriginLines(change.Before, change.After)
+		for j, origin := range next {
+			if origin >= 0 {
+				next[j] = origins[origin]
+			}
+		}
+		origins = next
+	}
+}
+
+func (r *Run) changeResult(path string, rec recipe.Recipe, ruleIndex int, hunk diff.Hunk, origins []int) Result {
+	// Hunks without old lines insert after OldStart; others replace from OldStart
+	first := hunk.OldStart
+	if hunk.OldLines > 0 {
+		first = hunk.OldStart - 1
+	}
+	start := originalLine(origins, first)
+	end := start
+	if hunk.OldLines > 0 {
+		end = originalLine(origins, first+hunk.OldLines-1) + 1
+	}
+
+	var inserted strings.Builder
+	for _, line := range hunk.Lines {
+		if line.Op == diff.Insert {
+			inserted.WriteString(line.Text)
+		}
+	}
+
+	artifact := ArtifactLocation{URI: path, URIBaseID: SourceRoot}
+	location := &Region{StartLine: start + 1}
+	if end > start {
+		location.EndLine = end
+	}
+
+	replacement := Replacement{
+		DeletedRegion: Region{StartLine: start + 1, StartColumn: 1, EndLine: end + 1, EndColumn: 1},
+	}
+	if inserted.Len() > 0 {
+		replacement.InsertedContent = &ArtifactContent{Text: inserted.String()}
+	}
+
+	return Result{
+		RuleID:    r.Tool.Driver.Rules[ruleIndex].ID,
+		RuleIndex: ruleIndex,
+		Level:     "warning",
+		Message:   Message{Text: rec.GetDisplayName()},
+		Locations: []Location{{PhysicalLocation: PhysicalLocation{
+			ArtifactLocation: artifact,
+			Region:           location,
+		}}},
+		Fixes: []Fix{{
+			Description: Message{Text: rec.GetDisplayName()},
+			ArtifactChanges: []ArtifactChange{{
+				ArtifactLocation: artifact,
+				Replacements:     []Replacement{replacement},
+			}},
+		}},
+	}
+}
+
+// originalLine returns the original zero-based line of intermediate line index. Lines
+// inserted by earlier recipes resolve to the position after the closest original line.
+func originalLine(origins []int, index int) int {
+	if index < len(origins) && origins[index] >= 0 {
+		return origins[index]
+	}
+	for i := min(index, len(origins)) - 1; i >= 0; i-- {
+		if origins[i] >= 0 {
+			return origins[i] + 1
+		}
+	}
+	return 0
+}
+
+func identity(n int) []int {
+	lines := make([]int, n)
+	for i := range lines {
+		lines[i] = i
+	}
+	return lines
+}
@@ -0,0 +1,126 @@
+package sarif
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+
+	"rewrite-migrate-java/pkg/recipe"
+)
+
+type namedRecipe struct {
+	*recipe.BaseRecipe
+}
+
+func (r *namedRecipe) GetVisitor() recipe.TreeVisitor {
+	return nil
+}
+
+func newNamedRecipe(name string) *namedRecipe {
+	return &namedRecipe{BaseRecipe: &recipe.BaseRecipe{
+		Name:        name,
+		DisplayName: name + " display",
+		Description: name + " description",
+	}}
+}
+
+func TestAddChangesMapsLinesToOriginalFile(t *testing.T) {
+	insertHeader := newNamedRecipe("header")
+	rename := newNamedRecipe("rename")
+
+	original := "a\nb\nc\n"
+	withHeader := "// header\na\nb\nc\n"
+	renamed := "// header\na\nB\nc\n"
+
+	log := NewLog("tool", "")
+	run := log.Runs[0]
+	run.AddChanges("src/A.java", []recipe.RecipeResult{
+		{Recipe: insertHeader, Before: original, After: withHeader},
+		{Recipe: rename, Before: withHeader, After: renamed},
+	})
+
+	if len(run.Tool.Driver.Rules) != 2 {
+		t.Fatalf("Expected 2 rules, got %d", len(run.Tool.Driver.Rules))
+	}
+	if len(run.Results) != 2 {
+		t.Fatalf("Expected 2 results, got %d", len(run.Results))
+	}
+
+	header := run.Results[0]
+	if header.RuleID != "header" || header.RuleIndex != 0 {
+		t.Errorf("Unexpected rule for first result: %s/%d", header.RuleID, header.RuleIndex)
+	}
+	replacement := header.Fixes[0].ArtifactChanges[0].Replacements[0]
+	if replacement.DeletedRegion != (Region{StartLine: 1, StartColumn: 1, EndLine: 1, EndColumn: 1}) {
+		t.Errorf("Unexpected insertion region %+v", replacement.DeletedRegion)
+	}
+	if replacement.InsertedContent == nil || replacement.InsertedContent.Text != "// header\n" {
+		t.Errorf("Unexpected inserted content %+v", replacement.InsertedContent)
+	}
+
+	// The rename touches line 3 of the intermediate content, which is line 2 of the original
+	renameResult := run.Results[1]
+	region := renameResult.Locations[0].PhysicalLocation.Region
+	if region.StartLine != 2 || region.EndLine != 2 {
+		t.Errorf("Unexpected rename location %+v", region)
+	}
+	replacement = renameResult.Fixes[0].ArtifactChanges[0].Replacements[0]
+	if replacement.DeletedRegion != (Region{StartLine: 2, StartColumn: 1, EndLine: 3, EndColumn: 1}) {
+		t.Errorf("Unexpected rename region %+v", replacement.DeletedRegion)
+	}
+	if replacement.InsertedContent.Text != "B\n" {
+		t.Errorf("Unexpected rename content %q", replacement.InsertedContent.Text)
+	}
+}
+
+// versionRecipe is a recipe with an option, like UpgradeJavaVersion
+type versionRecipe struct {
+	*recipe.BaseRecipe
+	Version int
+}
+
+func (r *versionRecipe) GetVisitor() recipe.TreeVisitor {
+	return nil
+}
+
+func TestAddRuleTellsRecipeOptionsApart(t *testing.T) {
+	newVersionRecipe := func(version int) *versionRecipe {
+		return &versionRecipe{BaseRecipe: &recipe.BaseRecipe{Name: "upgrade", DisplayName: "Upgrade"}, Version: version}
+	}
+
+	run := NewLog("tool", "").Runs[0]
+	run.AddChanges("pom.xml", []recipe.RecipeResult{
+		{Recipe: newVersionRecipe(11), Before: "8\n", After: "11\n"},
+		{Recipe: newVersionRecipe(17), Before: "11\n", After: "17\n"},
+	})
+
+	if len(run.Tool.Driver.Rules) != 2 {
+		t.Fatalf("Expected a rule per version, got %+v", run.Tool.Driver.Rules)
+	}
+	for i, id := range []string{"upgrade", "upgrade#2"} {
+		if result := run.Results[i]; result.RuleIndex != i || result.RuleID != id {
+			t.Errorf("Expected result %d to have rule %s/%d, got %s/%d", i, id, i, result.RuleID, result.RuleIndex)
+		}
+	}
+	if index := run.AddRule(newVersionRecipe(17)); index != 1 {
+		t.Errorf("Expected the rule of the same options to be reused, got index %d", index)
+	}
+}
+
+func TestWrite(t *testing.T) {
+	log := NewLog("tool", "https://example.com")
+	log.Runs[0].AddRule(newNamedRecipe("rule"))
+
+	var buf bytes.Buffer
+	if err := log.Write(&buf); err != nil {
+		t.Fatalf("Write failed: %v", err)
+	}
+
+	var decoded map[string]interface{}
+	if err := json.Unmarshal(buf.Bytes(), &decoded); err != nil {
+		t.Fatalf("Output is not valid JSON: %v", err)
+	}
+	if decoded["version"] != Version || decoded["$schema"] != Schema {
+		t.Errorf("Unexpected header %v", decoded)
+	}
+}
@@ -0,0 +1,269 @@
+package service
+
+import (
+	"archive/tar"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+
+	"rewrite-migrate-java/pkg/diff"
+	"rewrite-migrate-java/pkg/recipe"
+	"rewrite-migrate-java/pkg/runner"
+	"rewrite-migrate-java/pkg/textfile"
+)
+
+// MaxRequestBytes limits the size of posted sources
+const MaxRequestBytes = 32 << 20
+
+// SourcesFileResults is the data table listing every change, as in OpenRewrite
+const SourcesFileResults = "org.openrewrite.table.SourcesFileResults"
+
+// Server exposes the recipes of a registry over HTTP:
+//
+//	GET  /recipes             lists the recipes
+//	GET  /recipes/{name}      describes a recipe and its options
+//	POST /recipes/{name}/run  runs a recipe on posted files, as JSON or as a tar archive
+type Server struct {
+	registry *recipe.Registry
+}
+
+// NewServer creates a server for the recipes of registry
+func NewServer(registry *recipe.Registry) *Server {
+	return &Server{registry: registry}
+}
+
+// RecipeDescription describes a registered recipe
+type RecipeDescription struct {
+	Name        string              `json:"name"`
+	DisplayName string              `json:"displayName,omitempty"`
+	Description string              `json:"description,omitempty"`
+	Options     []OptionDescription `json:"options"`
+}
+
+type OptionDescription struct {
+	Name        string `json:"name"`
+	Description string `json:"description,omitempty"`
+	Required    bool   `json:"required"`
+}
+
+// RunRequest is the JSON body of a run. Files map paths to their content, and are visited
+// like a project: the sources below the source directories of each module, and the build files.
+type RunRequest struct {
+	Options map[string]string `json:"options"`
+	Files   map[string]string `json:"files"`
+}
+
+// RunResponse holds the diff of every changed file and the data tables of the run
+type RunResponse struct {
+	Recipe     string                         `json:"recipe"`
+	Results    []FileResult                   `json:"results"`
+	DataTables map[string][]SourcesFileResult `json:"dataTables"`
+}
+
+// FileResult is the outcome of running the recipe on a single posted file
+type FileResult struct {
+	Path    string   `json:"path"`
+	Diff    string   `json:"diff,omitempty"`
+	Recipes []string `json:"recipes,omitempty"`
+	Error   string   `json:"error,omitempty"`
+}
+
+// SourcesFileResult is a row of the SourcesFileResults data table
+type SourcesFileResult struct {
+	SourcePath          string  `json:"sourcePath"`
+	AfterSourcePath     string  `json:"afterSourcePath"`
+	ParentRecipe        string  `json:"parentRecipe"`
+	Recipe              string  `json:"recipe"`
+	EstimatedTimeSaving float64 `json:"estimatedTimeSaving"`
+}
+
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	path := strings.Trim(r.URL.Path, "/")
+	switch {
+	case path == "recipes":
+		if r.Method != http.MethodGet {
+			writeError(w, http.StatusMethodNotAllowed, fmt.Errorf("method %s not allowed", r.Method))
+			return
+		}
+		s.list(w)
+
+	case strings.HasPrefix(path, "recipes/") && strings.HasSuffix(path, "/run"):
+		if r.Method != http.MethodPost {
+			writeError(w, http.StatusMethodNotAllowed, fmt.Errorf("method %s not allowed", r.Method))
+			return
+		}
+		s.run(w, r, strings.TrimSuffix(strings.TrimPrefix(path, "recipes/"), "/run"))
+
+	case strings.HasPrefix(path, "recipes/"):
+		if r.Method != http.MethodGet {
+			writeError(w, http.StatusMethodNotAllowed, fmt.Errorf("method %s not allowed", r.Method))
+			return
+		}
+		s.describe(w, strings.TrimPrefix(path, "recipes/"))
+
+	default:
+		writeError(w, http.StatusNotFound, fmt.Errorf("no such endpoint /%s", path))
+	}
+}
+
+func (s *Server) list(w http.ResponseWriter) {
+	descriptions := []RecipeDescription{}
+	for _, name := range s.registry.Names() {
+		d, _ := s.registry.Lookup(name)
+		descriptions = append(descriptions, describe(d))
+	}
+	writeJSON(w, http.StatusOK, descriptions)
+}
+
+func (s *Server) describe(w http.ResponseWriter, name string) {
+	d, ok := s.registry.Lookup(name)
+	if !ok {
+		writeError(w, http.StatusNotFound, fmt.Errorf("unknown recipe %s", name))
+		return
+	}
+	writeJSON(w, http.StatusOK, describe(d))
+}
+
+func (s *Server) run(w http.ResponseWriter, r *http.Request, name string) {
+	if _, ok := s.registry.Lookup(name); !ok {
+		writeError(w, http.StatusNotFound, fmt.Errorf("unknown recipe %s", name))
+		return
+	}
+
+	request, err := readRunRequest(w, r)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+	rec, err := s.registry.New(name, request.Options)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	response, err := Run(recipe.NewExecutionContext(r.Context()), rec, request.Files)
+	if err != nil {
+		writeError(w, http.StatusServiceUnavailable, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, response)
+}
+
+// Run applies rec to files, which are laid out like a project, the way the command line
+// runs it on a project directory, and returns the diffs and data tables. It stops early
+// when the context of ctx is cancelled.
+func Run(ctx *recipe.ExecutionContext, rec recipe.Recipe, files map[string]string) (*RunResponse, error) {
+	results, err := runner.Run(ctx, rec, runner.MapFS(files), runner.Options{})
+	if err != nil {
+		return nil, err
+	}
+
+	response := &RunResponse{
+		Recipe:     rec.GetName(),
+		Results:    []FileResult{},
+		DataTables: map[string][]SourcesFileResult{SourcesFileResults: {}},
+	}
+	for _, result := range results {
+		if result.Err != nil {
+			response.Results = append(response.Results, FileResult{Path: result.Path, Error: result.Err.Error()})
+			continue
+		}
+		if !result.Changed() {
+			continue
+		}
+
+		fileResult := FileResult{
+			Path: result.Path,
+			Diff: diff.Unified("a/"+result.Path, "b/"+result.Path, result.Before, result.After, diff.DefaultContext),
+		}
+		for _, recipeResult := range result.RecipeResults {
+			if !recipeResult.Changed() {
+				continue
+			}
+			fileResult.Recipes = append(fileResult.Recipes, recipeResult.Recipe.GetName())
+			occurrences := len(diff.Hunks(recipeResult.Before, recipeResult.After, 0))
+			response.DataTables[SourcesFileResults] = append(response.DataTables[SourcesFileResults], SourcesFileResult{
+				SourcePath:          result.Path,
+				AfterSourcePath:     result.Path,
+				ParentRecipe:        rec.GetName(),
+				Recipe:              recipeResult.Recipe.GetName(),
+				EstimatedTimeSaving: recipeResult.Recipe.GetEstimatedEffortPerOccurrence().Seconds() * float64(occurrences),
+			})
+		}
+		response.Results = append(response.Results, fileResult)
+	}
+	return response, nil
+}
+
+// readRunRequest decodes a JSON run request, or a tar archive of sources with the
+// recipe options as query parameters
+func readRunRequest(w http.ResponseWriter, r *http.Request) (*RunRequest, error) {
+	body := http.MaxBytesReader(w, r.Body, MaxRequestBytes)
+
+	contentType := r.Header.Get("Content-Type")
+	if strings.HasPrefix(contentType, "application/x-tar") {
+		request := &RunRequest{Options: make(map[string]string), Files: make(map[string]string)}
+		for name, values := range r.URL.Query() {
+			request.Options[name] = values[len(values)-1]
+		}
+
+		archive := tar.NewReader(body)
+		for {
+			header, err := archive.Next()
+			if errors.Is(err, io.EOF) {
+				break
+			}
+			if err != nil {
+				return nil, fmt.Errorf("failed to read tar archive: %w", err)
+			}
+			if header.Typeflag != tar.TypeReg {
+				continue
+			}
+			data, err := io.ReadAll(archive)
+			if err != nil {
+				return nil, fmt.Errorf("failed to read %s from tar archive: %w", header.Name, err)
+			}
+			content, _ := textfile.Decode(data)
+			request.Files[strings.TrimPrefix(header.Name, "./")] = content
+		}
+		return request, nil
+	}
+
+	var request RunRequest
+	if err := json.NewDecoder(body).Decode(&request); err != nil {
+		return nil, fmt.Errorf("failed to decode run request: %w", err)
+	}
+	return &request, nil
+}
+
+func describe(d *recipe.Descriptor) RecipeDescription {
+	description := RecipeDescription{
+		Name:        d.Name,
+		DisplayName: d.DisplayName,
+		Description: d.Description,
+		Options:     []OptionDescription{},
+	}
+	for _, option := range d.Options {
+		description.Options = append(description.Options, OptionDescription{
+			Name:        option.Name,
+			Description: option.Description,
+			Required:    option.Required,
+		})
+	}
+	return description
+}
+
+func writeJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	encoder.Encode(v)
+}
+
+func writeError(w http.ResponseWriter, status int, err error) {
+	writeJSON(w, status, map[string]string{"error": err.Error()})
+}
@@ -0,0 +1,183 @@
+package service
+
+import (
+	"archive/tar"
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"rewrite-migrate-java/pkg/migrate"
+	"rewrite-migrate-java/pkg/recipe"
+)
+
+const base64Source = `package com.example;
+
+import sun.misc.BASE64Encoder;
+
+public class Example {
+    public String encode(byte[] data) {
+        return new BASE64Encoder().encode(data);
+    }
+}
+`
+
+const pom = `<project>
+  <build>
+    <plugins>
+      <plugin>
+        <artifactId>maven-compiler-plugin</artifactId>
+        <configuration>
+          <source>11</source>
+          <target>11</target>
+        </configuration>
+      </plugin>
+    </plugins>
+  </build>
+</project>
+`
+
+func do(t *testing.T, method, target, contentType string, body []byte) *httptest.ResponseRecorder {
+	t.Helper()
+	req := httptest.NewRequest(method, target, bytes.NewReader(body))
+	if contentType != "" {
+		req.Header.Set("Content-Type", contentType)
+	}
+	rec := httptest.NewRecorder()
+	NewServer(migrate.NewRegistry()).ServeHTTP(rec, req)
+	return rec
+}
+
+func TestListAndDescribe(t *testing.T) {
+	rec := do(t, http.MethodGet, "/recipes", "", nil)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("Expected 200, got %d: %s", rec.Code, rec.Body)
+	}
+	var recipes []RecipeDescription
+	if err := json.Unmarshal(rec.Body.Bytes(), &recipes); err != nil {
+		t.Fatal(err)
+	}
+	if len(recipes) != len(migrate.NewRegistry().Names()) {
+		t.Errorf("Expected every registered recipe, got %d", len(recipes))
+	}
+
+	rec = do(t, http.MethodGet, "/recipes/org.openrewrite.java.migrate.UpgradeJavaVersion", "", nil)
+	var description RecipeDescription
+	if err := json.Unmarshal(rec.Body.Bytes(), &description); err != nil {
+		t.Fatal(err)
+	}
+	if description.DisplayName != "Upgrade Java version" || len(description.Options) != 1 || !description.Options[0].Required {
+		t.Errorf("Unexpected description %+v", description)
+	}
+
+	if rec := do(t, http.MethodGet, "/recipes/unknown", "", nil); rec.Code != http.StatusNotFound {
+		t.Errorf("Expected 404 for an unknown recipe, got %d", rec.Code)
+	}
+}
+
+func TestRunJSON(t *testing.T) {
+	body, _ := json.Marshal(RunRequest{
+		Files: map[string]string{
+			"src/main/java/com/example/Example.java": base64Source,
+			"src/main/java/com/example/Plain.java":   "package com.example;\nclass Plain {}\n",
+		},
+	})
+	rec := do(t, http.MethodPost, "/recipes/org.openrewrite.java.migrate.UseJavaUtilBase64/run", "application/json", body)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("Expected 200, got %d: %s", rec.Code, rec.Body)
+	}
+
+	var response RunResponse
+	if err := json.Unmarshal(rec.Body.Bytes(), &response); err != nil {
+		t.Fatal(err)
+	}
+	if len(response.Results) != 1 || response.Results[0].Path != "src/main/java/com/example/Example.java" {
+		t.Fatalf("Expected only Example.java to change, got %+v", response.Results)
+	}
+	if !strings.Contains(response.Results[0].Diff, "+import java.util.Base64;") {
+		t.Errorf("Unexpected diff:\n%s", response.Results[0].Diff)
+	}
+	rows := response.DataTables[SourcesFileResults]
+	if len(rows) != 1 || rows[0].Recipe != "org.openrewrite.java.migrate.UseJavaUtilBase64" {
+		t.Errorf("Unexpected data table %+v", rows)
+	}
+}
+
+func TestRunTarWithOptions(t *testing.T) {
+	var archive bytes.Buffer
+	writer := tar.NewWriter(&archive)
+	writer.WriteHeader(&tar.Header{Name: "./pom.xml", Mode: 0644, Size: int64(len(pom)), Typeflag: tar.TypeReg})
+	writer.Write([]byte(pom))
+	writer.Close()
+
+	target := "/recipes/org.openrewrite.java.migrate.UpgradeJavaVersion/run?version=17"
+	rec := do(t, http.MethodPost, target, "application/x-tar", archive.Bytes())
+	if rec.Code != http.StatusOK {
+		t.Fatalf("Expected 200, got %d: %s", rec.Code, rec.Body)
+	}
+	var response RunResponse
+	if err := json.Unmarshal(rec.Body.Bytes(), &response); err != nil {
+		t.Fatal(err)
+	}
+	if len(response.Results) != 1 || response.Results[0].Path != "pom.xml" {
+		t.Fatalf("Expected pom.xml to change, got %+v", response.Results)
+	}
+
+	// The version option is required
+	target = "/recipes/org.openrewrite.java.migrate.UpgradeJavaVersion/run"
+	if rec := do(t, http.MethodPost, target, "application/x-tar", archive.Bytes()); rec.Code != http.StatusBadRequest {
+		t.Errorf("Expected 400 without the version option, got %d", rec.Code)
+	}
+}
+
+func TestRunStopsWhenCancelled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	_, err := Run(recipe.NewExecutionContext(ctx), migrate.NewUseJavaUtilBase64("", false), map[string]string{
+		"src/main/java/com/example/Example.java": base64Source,
+	})
+	if err == nil {
+		t.Error("Expected a cancelled run to fail")
+	}
+}
+
+func TestRunResolvesParentPOMs(t *testing.T) {
+	parent := `<project>
+  <groupId>com.example</groupId>
+  <artifactId>parent</artifactId>
+  <version>1</version>
+  <modules>
+    <module>core</module>
+  </modules>
+  <properties>
+    <maven.compiler.target>17</maven.compiler.target>
+  </properties>
+</project>
+`
+	core := `<project>
+  <parent>
+    <groupId>com.example</groupId>
+    <artifactId>parent</artifactId>
+    <version>1</version>
+  </parent>
+  <artifactId>core</artifactId>
+  <properties>
+    <maven.compiler.source>17</maven.compiler.source>
+  </properties>
+</project>
+`
+	// The source of core only becomes release with the target it inherits from the parent
+	response, err := Run(recipe.NewExecutionContext(context.Background()), migrate.NewUseMavenCompilerReleaseProperty(),
+		map[string]string{"pom.xml": parent, "core/pom.xml": core})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(response.Results) != 1 || response.Results[0].Path != "core/pom.xml" ||
+		!strings.Contains(response.Results[0].Diff, "+    <maven.compiler.release>17</maven.compiler.release>") {
+		t.Errorf("Expected core/pom.xml to use release, got %+v", response.Results)
+	}
+}
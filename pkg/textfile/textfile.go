@@ -0,0 +1,163 @@
+package textfile
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"unicode/utf8"
+)
+
+// Supported charsets
+const (
+	UTF8      = "UTF-8"
+	ISO8859_1 = "ISO-8859-1"
+)
+
+var utf8BOM = []byte{0xEF, 0xBB, 0xBF}
+
+// Format describes how a text file is stored on disk, so that it can be written back the same way
+type Format struct {
+	Charset string
+	BOM     bool
+	// CRLF is set when every line of the file ends in \r\n
+	CRLF            bool
+	TrailingNewline bool
+	Mode            fs.FileMode
+}
+
+// ReadFS reads the file name from fsys and returns its content decoded and with LF line endings
+func ReadFS(fsys fs.FS, name string) (string, Format, error) {
+	info, err := fs.Stat(fsys, name)
+	if err != nil {
+		return "", Format{}, fmt.Errorf("failed to read file %s: %w", name, err)
+	}
+	data, err := fs.ReadFile(fsys, name)
+	if err != nil {
+		return "", Format{}, fmt.Errorf("failed to read file %s: %w", name, err)
+	}
+
+	content, format := Decode(data)
+	if mode := info.Mode().Perm(); mode != 0 {
+		format.Mode = mode
+	}
+	return content, format, nil
+}
+
+// Decode detects the format of data and returns its content as a UTF-8 string with LF
+// line endings. Data that is not valid UTF-8 is decoded as ISO-8859-1.
+func Decode(data []byte) (string, Format) {
+	format := Format{Charset: UTF8, Mode: 0644}
+
+	if bytes.HasPrefix(data, utf8BOM) {
+		format.BOM = true
+		data = data[len(utf8BOM):]
+	}
+
+	var content string
+	if utf8.Valid(data) {
+		content = string(data)
+	} else {
+		format.Charset = ISO8859_1
+		runes := make([]rune, len(data))
+		for i, b := range data {
+			runes[i] = rune(b)
+		}
+		content = string(runes)
+	}
+
+	format.TrailingNewline = strings.HasSuffix(content, "\n")
+
+	// Only files with consistent CRLF endings are normalized; mixed files are kept as they are
+	lines := strings.Count(content, "\n")
+	if lines > 0 && strings.Count(content, "\r\n") == lines {
+		format.CRLF = true
+		content = strings.ReplaceAll(content, "\r\n", "\n")
+	}
+
+	return content, format
+}
+
+// DecodedCharsetReader is an xml.Decoder CharsetReader for documents already decoded by
+// Decode. Their declared encoding, such as ISO-8859-1, no longer applies, so the input is
+// returned as is.
+func DecodedCharsetReader(charset string, input io.Reader) (io.Reader, error) {
+	return input, nil
+}
+
+// Encode converts LF content back into the charset, line endings, BOM and trailing
+// newline of the original file
+func (f Format) Encode(content string) ([]byte, error) {
+	if content != "" {
+		hasNewline := strings.HasSuffix(content, "\n")
+		if f.TrailingNewline && !hasNewline {
+			content += "\n"
+		} else if !f.TrailingNewline && hasNewline {
+			content = strings.TrimSuffix(content, "\n")
+		}
+	}
+
+	if f.CRLF {
+		content = strings.ReplaceAll(content, "\r\n", "\n")
+		content = strings.ReplaceAll(content, "\n", "\r\n")
+	}
+
+	var buf bytes.Buffer
+	if f.BOM {
+		buf.Write(utf8BOM)
+	}
+
+	switch f.Charset {
+	case "", UTF8:
+		buf.WriteString(content)
+	case ISO8859_1:
+		for _, r := range content {
+			if r > 0xFF {
+				return nil, fmt.Errorf("character %q cannot be encoded in %s", r, f.Charset)
+			}
+			buf.WriteByte(byte(r))
+		}
+	default:
+		return nil, fmt.Errorf("unsupported charset %s", f.Charset)
+	}
+
+	return buf.Bytes(), nil
+}
+
+// WriteBytes atomically replaces path with data and mode. The data is written to a
+// temporary file in the same directory, which is then renamed over path, so readers
+// and interrupted runs only ever see the old or the new content.
+func WriteBytes(path string, data []byte, mode fs.FileMode) error {
+	if mode == 0 {
+		mode = 0644
+	}
+
+	temp, err := os.CreateTemp(filepath.Dir(path), "."+filepath.Base(path)+".*.tmp")
+	if err != nil {
+		return fmt.Errorf("failed to write file %s: %w", path, err)
+	}
+	tempPath := temp.Name()
+	defer os.Remove(tempPath) // No-op once the rename succeeded
+
+	if _, err := temp.Write(data); err != nil {
+		temp.Close()
+		return fmt.Errorf("failed to write file %s: %w", path, err)
+	}
+	if err := temp.Sync(); err != nil {
+		temp.Close()
+		return fmt.Errorf("failed to write file %s: %w", path, err)
+	}
+	if err := temp.Close(); err != nil {
+		return fmt.Errorf("failed to write file %s: %w", path, err)
+	}
+	if err := os.Chmod(tempPath, mode); err != nil {
+		return fmt.Errorf("failed to set mode of %s: %w", path, err)
+	}
+	if err := os.Rename(tempPath, path); err != nil {
+		return fmt.Errorf("failed to replace file %s: %w", path, err)
+	}
+	return nil
+}
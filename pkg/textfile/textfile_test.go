@@ -0,0 +1,112 @@
+package textfile
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestDecodeEncodeRoundTrip(t *testing.T) {
+	tests := []struct {
+		name    string
+		data    string
+		content string
+		format  Format
+	}{
+		{"plain", "a\nb\n", "a\nb\n", Format{Charset: UTF8, TrailingNewline: true}},
+		{"no trailing newline", "a\nb", "a\nb", Format{Charset: UTF8}},
+		{"crlf", "a\r\nb\r\n", "a\nb\n", Format{Charset: UTF8, CRLF: true, TrailingNewline: true}},
+		{"mixed endings", "a\r\nb\n", "a\r\nb\n", Format{Charset: UTF8, TrailingNewline: true}},
+		{"bom", "\xEF\xBB\xBFa\n", "a\n", Format{Charset: UTF8, BOM: true, TrailingNewline: true}},
+		{"latin1", "caf\xE9\r\n", "café\n", Format{Charset: ISO8859_1, CRLF: true, TrailingNewline: true}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			content, format := Decode([]byte(tt.data))
+			format.Mode = 0
+			if content != tt.content {
+				t.Errorf("Expected content %q, got %q", tt.content, content)
+			}
+			if format != tt.format {
+				t.Errorf("Expected format %+v, got %+v", tt.format, format)
+			}
+
+			encoded, err := format.Encode(content)
+			if err != nil {
+				t.Fatalf("Encode failed: %v", err)
+			}
+			if string(encoded) != tt.data {
+				t.Errorf("Expected round trip to %q, got %q", tt.data, encoded)
+			}
+		})
+	}
+}
+
+func TestEncodeKeepsOriginalConventions(t *testing.T) {
+	_, format := Decode([]byte("caf\xE9\r\nend"))
+
+	// Recipes produce LF content and may add a trailing newline
+	encoded, err := format.Encode("café\ninserted\nend\n")
+	if err != nil {
+		t.Fatalf("Encode failed: %v", err)
+	}
+	if want := "caf\xE9\r\ninserted\r\nend"; string(encoded) != want {
+		t.Errorf("Expected %q, got %q", want, encoded)
+	}
+
+	if _, err := format.Encode("日本"); err == nil {
+		t.Error("Expected an error for characters outside ISO-8859-1")
+	}
+}
+
+func TestWriteBytesPreservesMode(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "gradlew")
+	if err := os.WriteFile(path, []byte("#!/bin/sh\r\n"), 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	content, format, err := ReadFS(os.DirFS(dir), "gradlew")
+	if err != nil {
+		t.Fatalf("ReadFS failed: %v", err)
+	}
+	data, err := format.Encode(content + "exec java\n")
+	if err != nil {
+		t.Fatalf("Encode failed: %v", err)
+	}
+	if err := WriteBytes(path, data, format.Mode); err != nil {
+		t.Fatalf("WriteBytes failed: %v", err)
+	}
+
+	info, err := os.Stat(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info.Mode().Perm() != 0755 {
+		t.Errorf("Expected mode 0755, got %v", info.Mode().Perm())
+	}
+	data, _ = os.ReadFile(path)
+	if string(data) != "#!/bin/sh\r\nexec java\r\n" {
+		t.Errorf("Unexpected content %q", data)
+	}
+}
+
+func TestWriteBytesLeavesNoTemporaryFiles(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "A.java")
+	if err := WriteBytes(path, []byte("class A {}\n"), 0); err != nil {
+		t.Fatalf("WriteBytes failed: %v", err)
+	}
+
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 1 || entries[0].Name() != "A.java" {
+		t.Errorf("Expected only A.java, got %v", entries)
+	}
+	if info, _ := os.Stat(path); info.Mode().Perm() != 0644 {
+		t.Errorf("Expected default mode 0644, got %v", info.Mode().Perm())
+	}
+}